@@ -0,0 +1,547 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+
+	"github.com/nijaru/nano-link/internal/blocklist"
+	"github.com/nijaru/nano-link/internal/config"
+	"github.com/nijaru/nano-link/internal/geoip"
+	"github.com/nijaru/nano-link/internal/handlers"
+	"github.com/nijaru/nano-link/internal/middleware"
+	"github.com/nijaru/nano-link/internal/probes"
+	"github.com/nijaru/nano-link/internal/qr"
+	"github.com/nijaru/nano-link/internal/repository"
+	"github.com/nijaru/nano-link/internal/service"
+	"github.com/nijaru/nano-link/pkg/client"
+)
+
+// testAdminToken is the ADMIN_TOKEN of the test server
+const testAdminToken = "contract-test-admin-token"
+
+// testServer runs setupRoutes on a temporary database
+type testServer struct {
+	app *fiber.App
+	url string
+}
+
+// newTestServer wires the services the way serve does, with destinations on
+// example.com allowlisted so no DNS lookups are made
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "nano-link.db")
+	t.Setenv("DB_PATH", dbPath)
+	t.Setenv("BASE_URL", "")
+	t.Setenv("ADMIN_TOKEN", testAdminToken)
+	t.Setenv("DESTINATION_ALLOWLIST", "example.com,*.example.com")
+	t.Setenv("WEBHOOK_POLL_INTERVAL", "50ms")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	repo, err := repository.NewSQLiteRepository(dbPath)
+	if err != nil {
+		t.Fatalf("open repository: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	links, err := handlers.NewLinkBuilder(cfg.BaseURL)
+	if err != nil {
+		t.Fatalf("link builder: %v", err)
+	}
+	blocked, err := blocklist.Load(cfg.BlocklistFiles)
+	if err != nil {
+		t.Fatalf("load blocklist: %v", err)
+	}
+
+	webhookService := service.NewWebhookService(repo)
+	webhookHandler := handlers.NewWebhookHandler(&webhookService)
+	webhookTask := newWebhookTask(cfg, repo, &webhookService)
+	webhookTask.Start()
+	t.Cleanup(webhookTask.Stop)
+
+	urlService, err := newURLService(cfg, repo, &geoip.Resolver{}, links.Host(), blocked, webhookTask)
+	if err != nil {
+		t.Fatalf("URL service: %v", err)
+	}
+	urlHandler := handlers.NewURLHandler(&urlService, links)
+	collectionService := service.NewCollectionService(repo, repo)
+	collectionHandler := handlers.NewCollectionHandler(&collectionService, links)
+	domainService := service.NewDomainService(repo, links.Host())
+	domainHandler := handlers.NewDomainHandler(&domainService)
+	tagService := service.NewTagService(repo, repo, webhookTask)
+	tagHandler := handlers.NewTagHandler(&tagService, &urlService, links)
+	reportService := service.NewReportService(repo, repo, cfg.ReportThreshold, webhookTask)
+	reportHandler := handlers.NewReportHandler(&reportService, &urlService)
+	apiKeyService := service.NewAPIKeyService(repo)
+	qrGenerator, err := qr.NewGenerator(cfg.QRLogoFile, cfg.QRCacheSize)
+	if err != nil {
+		t.Fatalf("QR generator: %v", err)
+	}
+	qrHandler := handlers.NewQRHandler(&urlService, qrGenerator, links)
+	prober := probes.NewProber(repo, dbPath, 0)
+	prober.AddHeartbeat(webhookTask.Heartbeat())
+	probeHandler := handlers.NewProbeHandler(prober)
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler:          handlers.ErrorHandler,
+		DisableStartupMessage: true,
+	})
+	setupRoutes(app, middleware.AdminAuth(cfg.AdminToken, &apiKeyService), urlHandler, tagHandler, qrHandler, collectionHandler, domainHandler, reportHandler, webhookHandler, probeHandler)
+
+	server := httptest.NewServer(adaptor.FiberApp(app))
+	t.Cleanup(server.Close)
+	return &testServer{app: app, url: server.URL}
+}
+
+// contractTransport checks every response against the OpenAPI document and
+// reports mismatches to the running test
+type contractTransport struct {
+	spec *apiSpec
+
+	mu sync.Mutex
+	t  *testing.T
+}
+
+func (c *contractTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := c.spec.checkResponse(req.Method, req.URL.Path, resp.StatusCode, resp.Header.Get("Content-Type"), body); err != nil {
+		c.mu.Lock()
+		c.t.Errorf("%s %s: %d does not match openapi.json: %v", req.Method, req.URL.Path, resp.StatusCode, err)
+		c.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// setTest directs mismatches to a subtest
+func (c *contractTransport) setTest(t *testing.T) {
+	c.mu.Lock()
+	c.t = t
+	c.mu.Unlock()
+}
+
+// clientStep calls one client method; steps run in order and share state.
+// The name starts with the method, optionally followed by "/case".
+type clientStep struct {
+	name string
+	run  func(t *testing.T)
+}
+
+// TestClientContract runs every client method against the server routes and
+// checks each response against openapi.json
+func TestClientContract(t *testing.T) {
+	server := newTestServer(t)
+	transport := &contractTransport{spec: loadSpec(t), t: t}
+	httpClient := &http.Client{Transport: transport, Timeout: 10 * time.Second}
+
+	admin, err := client.New(server.url, client.WithToken(testAdminToken), client.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	anonymous, err := client.New(server.url, client.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	// Webhook deliveries go to a local receiver
+	var received sync.WaitGroup
+	received.Add(1)
+	var receivedOnce sync.Once
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedOnce.Do(received.Done)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	ctx := context.Background()
+	var (
+		collection *client.Collection
+		webhook    *client.Webhook
+		delivery   *client.WebhookDelivery
+		reports    []*client.ReportReceipt
+	)
+	strPtr := func(s string) *string { return &s }
+
+	steps := []clientStep{
+		{"Health", func(t *testing.T) {
+			health, err := anonymous.Health(ctx)
+			if err != nil || health.Status != "ok" {
+				t.Fatalf("Health() = %+v, %v", health, err)
+			}
+		}},
+		{"Livez", func(t *testing.T) {
+			report, err := anonymous.Livez(ctx)
+			if err != nil || report.Status != "ok" {
+				t.Fatalf("Livez() = %+v, %v", report, err)
+			}
+		}},
+		{"Readyz", func(t *testing.T) {
+			report, err := anonymous.Readyz(ctx)
+			if err != nil || report.Status != "ok" {
+				t.Fatalf("Readyz() = %+v, %v", report, err)
+			}
+		}},
+		{"CreateDomain", func(t *testing.T) {
+			domain, err := admin.CreateDomain(ctx, "go.example.com")
+			if err != nil || domain.Host != "go.example.com" {
+				t.Fatalf("CreateDomain() = %+v, %v", domain, err)
+			}
+		}},
+		{"CreateDomain/unused", func(t *testing.T) {
+			if _, err := admin.CreateDomain(ctx, "old.example.com"); err != nil {
+				t.Fatalf("CreateDomain() error = %v", err)
+			}
+		}},
+		{"CreateDomain/unauthorized", func(t *testing.T) {
+			_, err := anonymous.CreateDomain(ctx, "new.example.com")
+			if !hasStatus(err, http.StatusUnauthorized) {
+				t.Fatalf("CreateDomain() without token error = %v, want 401", err)
+			}
+		}},
+		{"ListDomains", func(t *testing.T) {
+			domains, err := anonymous.ListDomains(ctx)
+			if err != nil || len(domains) != 2 {
+				t.Fatalf("ListDomains() = %v, %v", domains, err)
+			}
+		}},
+		{"CreateURL", func(t *testing.T) {
+			url, err := anonymous.CreateURL(ctx, client.CreateURLRequest{
+				URL:        "https://example.com/docs",
+				CustomCode: "docs",
+				GeoTargets: map[string]string{"DE": "https://example.com/de/docs"},
+				Tags:       []string{"launch"},
+				Folder:     "marketing",
+				Title:      "Docs",
+				Notes:      "internal",
+			})
+			if err != nil {
+				t.Fatalf("CreateURL() error = %v", err)
+			}
+			if url.URL.ShortCode != "docs" || !strings.HasSuffix(url.ShortURL, "/docs") {
+				t.Errorf("CreateURL() = %s %s, want docs", url.URL.ShortCode, url.ShortURL)
+			}
+		}},
+		{"CreateURL/domain", func(t *testing.T) {
+			url, err := anonymous.CreateURL(ctx, client.CreateURLRequest{
+				URL:        "https://www.example.com/",
+				CustomCode: "home",
+				Domain:     "go.example.com",
+			})
+			if err != nil || url.ShortURL != "http://go.example.com/home" {
+				t.Fatalf("CreateURL() = %+v, %v", url, err)
+			}
+		}},
+		{"CreateURL/invalid", func(t *testing.T) {
+			_, err := anonymous.CreateURL(ctx, client.CreateURLRequest{URL: "not a url"})
+			if !client.IsBadRequest(err) {
+				t.Fatalf("CreateURL() error = %v, want 400", err)
+			}
+		}},
+		{"ForDomain", func(t *testing.T) {
+			url, err := anonymous.ForDomain("go.example.com").GetURL(ctx, "home")
+			if err != nil || url.URL.OriginalURL != "https://www.example.com/" {
+				t.Fatalf("ForDomain().GetURL() = %+v, %v", url, err)
+			}
+		}},
+		{"GetURL", func(t *testing.T) {
+			url, err := anonymous.GetURL(ctx, "docs")
+			if err != nil {
+				t.Fatalf("GetURL() error = %v", err)
+			}
+			if url.URL.Title != "Docs" || url.URL.Notes != "" {
+				t.Errorf("GetURL() title %q notes %q, want public fields only", url.URL.Title, url.URL.Notes)
+			}
+		}},
+		{"GetURL/missing", func(t *testing.T) {
+			_, err := anonymous.GetURL(ctx, "missing")
+			if !client.IsNotFound(err) {
+				t.Fatalf("GetURL() error = %v, want 404", err)
+			}
+		}},
+		{"UpdateURL", func(t *testing.T) {
+			url, err := admin.UpdateURL(ctx, "docs", client.UpdateURLRequest{
+				Title: strPtr("Documentation"),
+				Notes: strPtr("launch docs"),
+			})
+			if err != nil || url.URL.Title != "Documentation" || url.URL.Notes != "launch docs" {
+				t.Fatalf("UpdateURL() = %+v, %v", url, err)
+			}
+		}},
+		{"UpdateURL/unauthorized", func(t *testing.T) {
+			_, err := anonymous.UpdateURL(ctx, "docs", client.UpdateURLRequest{Title: strPtr("Hijacked")})
+			if !hasStatus(err, http.StatusUnauthorized) {
+				t.Fatalf("UpdateURL() without token error = %v, want 401", err)
+			}
+		}},
+		{"ListURLs", func(t *testing.T) {
+			page, err := anonymous.ListURLs(ctx, client.ListURLsOptions{Limit: 10, Tag: "launch"})
+			if err != nil || len(page.URLs) != 1 || page.URLs[0].URL.ShortCode != "docs" {
+				t.Fatalf("ListURLs() = %+v, %v", page, err)
+			}
+		}},
+		{"GetCountryVisits", func(t *testing.T) {
+			if _, err := anonymous.GetCountryVisits(ctx, "docs"); err != nil {
+				t.Fatalf("GetCountryVisits() error = %v", err)
+			}
+		}},
+		{"GetPreview", func(t *testing.T) {
+			preview, err := anonymous.GetPreview(ctx, "docs")
+			if err != nil || preview.Destination != "https://example.com/docs" {
+				t.Fatalf("GetPreview() = %+v, %v", preview, err)
+			}
+		}},
+		{"GetQRCode", func(t *testing.T) {
+			image, contentType, err := anonymous.GetQRCode(ctx, "docs", client.QROptions{Size: 128})
+			if err != nil || contentType != "image/png" || !bytes.HasPrefix(image, []byte("\x89PNG")) {
+				t.Fatalf("GetQRCode() = %d bytes %q, %v", len(image), contentType, err)
+			}
+		}},
+		{"GetQRCode/svg", func(t *testing.T) {
+			image, contentType, err := anonymous.GetQRCode(ctx, "docs", client.QROptions{Format: "svg"})
+			if err != nil || !strings.HasPrefix(contentType, "image/svg+xml") || !bytes.Contains(image, []byte("<svg")) {
+				t.Fatalf("GetQRCode() = %d bytes %q, %v", len(image), contentType, err)
+			}
+		}},
+		{"GetStats", func(t *testing.T) {
+			stats, err := anonymous.GetStats(ctx)
+			if err != nil || stats.TotalURLs != 2 {
+				t.Fatalf("GetStats() = %+v, %v", stats, err)
+			}
+		}},
+		{"AddTags", func(t *testing.T) {
+			url, err := admin.AddTags(ctx, "docs", "email")
+			if err != nil || len(url.URL.Tags) != 2 {
+				t.Fatalf("AddTags() = %+v, %v", url, err)
+			}
+		}},
+		{"RemoveTag", func(t *testing.T) {
+			if err := admin.RemoveTag(ctx, "docs", "email"); err != nil {
+				t.Fatalf("RemoveTag() error = %v", err)
+			}
+		}},
+		{"SetFolder", func(t *testing.T) {
+			url, err := admin.SetFolder(ctx, "docs", "sales")
+			if err != nil || url.URL.Folder != "sales" {
+				t.Fatalf("SetFolder() = %+v, %v", url, err)
+			}
+		}},
+		{"ListTags", func(t *testing.T) {
+			tags, err := anonymous.ListTags(ctx)
+			if err != nil || len(tags) != 1 || tags[0].Tag != "launch" {
+				t.Fatalf("ListTags() = %v, %v", tags, err)
+			}
+		}},
+		{"GetTagStats", func(t *testing.T) {
+			stats, err := anonymous.GetTagStats(ctx, "launch")
+			if err != nil || stats.TotalURLs != 1 {
+				t.Fatalf("GetTagStats() = %+v, %v", stats, err)
+			}
+		}},
+		{"ListFolders", func(t *testing.T) {
+			folders, err := anonymous.ListFolders(ctx)
+			if err != nil || len(folders) != 1 || folders[0].Folder != "sales" {
+				t.Fatalf("ListFolders() = %v, %v", folders, err)
+			}
+		}},
+		{"ClearFolder", func(t *testing.T) {
+			url, err := admin.ClearFolder(ctx, "docs")
+			if err != nil || url.URL.Folder != "" {
+				t.Fatalf("ClearFolder() = %+v, %v", url, err)
+			}
+		}},
+		{"CreateCollection", func(t *testing.T) {
+			collection, err = admin.CreateCollection(ctx, client.CreateCollectionRequest{
+				Slug:  "team",
+				Title: "Team links",
+				Items: []client.CollectionItemRequest{{Code: "docs", Title: "Docs"}},
+			})
+			if err != nil || len(collection.Items) != 1 {
+				t.Fatalf("CreateCollection() = %+v, %v", collection, err)
+			}
+		}},
+		{"AddCollectionItem", func(t *testing.T) {
+			item, err := admin.AddCollectionItem(ctx, "team", client.CollectionItemRequest{
+				Code:   "home",
+				Domain: "go.example.com",
+				Title:  "Home",
+			})
+			if err != nil || item.ShortURL != "http://go.example.com/home" {
+				t.Fatalf("AddCollectionItem() = %+v, %v", item, err)
+			}
+			collection.Items = append(collection.Items, *item)
+		}},
+		{"ReorderCollectionItems", func(t *testing.T) {
+			first, second := collection.Items[0].ID, collection.Items[1].ID
+			reordered, err := admin.ReorderCollectionItems(ctx, "team", []int64{second, first})
+			if err != nil || reordered.Items[0].ID != second {
+				t.Fatalf("ReorderCollectionItems() = %+v, %v", reordered, err)
+			}
+		}},
+		{"GetCollection", func(t *testing.T) {
+			got, err := anonymous.GetCollection(ctx, "team")
+			if err != nil || len(got.Items) != 2 || got.Items[0].Title != "Home" {
+				t.Fatalf("GetCollection() = %+v, %v", got, err)
+			}
+		}},
+		{"RemoveCollectionItem", func(t *testing.T) {
+			if err := admin.RemoveCollectionItem(ctx, "team", collection.Items[0].ID); err != nil {
+				t.Fatalf("RemoveCollectionItem() error = %v", err)
+			}
+		}},
+		{"DeleteCollection", func(t *testing.T) {
+			if err := admin.DeleteCollection(ctx, "team"); err != nil {
+				t.Fatalf("DeleteCollection() error = %v", err)
+			}
+			if _, err := anonymous.GetCollection(ctx, "team"); !client.IsNotFound(err) {
+				t.Fatalf("GetCollection() after delete error = %v, want 404", err)
+			}
+		}},
+		{"DeleteDomain", func(t *testing.T) {
+			if err := admin.DeleteDomain(ctx, "old.example.com"); err != nil {
+				t.Fatalf("DeleteDomain() error = %v", err)
+			}
+		}},
+		{"CreateWebhook", func(t *testing.T) {
+			webhook, err = admin.CreateWebhook(ctx, client.CreateWebhookRequest{
+				URL:    receiver.URL,
+				Events: []string{client.EventLinkCreated},
+			})
+			if err != nil || webhook.Secret == "" {
+				t.Fatalf("CreateWebhook() = %+v, %v", webhook, err)
+			}
+
+			// A new link queues a delivery to the receiver
+			if _, err := anonymous.CreateURL(ctx, client.CreateURLRequest{URL: "https://example.com/news", CustomCode: "news"}); err != nil {
+				t.Fatalf("CreateURL() error = %v", err)
+			}
+		}},
+		{"ListWebhooks", func(t *testing.T) {
+			webhooks, err := admin.ListWebhooks(ctx)
+			if err != nil || len(webhooks) != 1 || webhooks[0].Secret != "" {
+				t.Fatalf("ListWebhooks() = %+v, %v", webhooks, err)
+			}
+		}},
+		{"ListDeliveries", func(t *testing.T) {
+			received.Wait()
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				deliveries, err := admin.ListDeliveries(ctx, client.ListDeliveriesOptions{Status: "delivered"})
+				if err != nil {
+					t.Fatalf("ListDeliveries() error = %v", err)
+				}
+				if len(deliveries) > 0 {
+					delivery = deliveries[0]
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("ListDeliveries() found no delivered delivery")
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+		}},
+		{"ListWebhookDeliveries", func(t *testing.T) {
+			deliveries, err := admin.ListWebhookDeliveries(ctx, webhook.ID, client.ListDeliveriesOptions{Limit: 10})
+			if err != nil || len(deliveries) != 1 || deliveries[0].Event != client.EventLinkCreated {
+				t.Fatalf("ListWebhookDeliveries() = %+v, %v", deliveries, err)
+			}
+		}},
+		{"RetryDelivery", func(t *testing.T) {
+			if err := admin.RetryDelivery(ctx, delivery.ID); err != nil {
+				t.Fatalf("RetryDelivery() error = %v", err)
+			}
+		}},
+		{"DeleteWebhook", func(t *testing.T) {
+			if err := admin.DeleteWebhook(ctx, webhook.ID); err != nil {
+				t.Fatalf("DeleteWebhook() error = %v", err)
+			}
+		}},
+		{"ReportURL", func(t *testing.T) {
+			for _, code := range []string{"docs", "news"} {
+				receipt, err := anonymous.ReportURL(ctx, code, client.CreateReportRequest{Reason: "spam", Details: "unsolicited"})
+				if err != nil {
+					t.Fatalf("ReportURL(%s) error = %v", code, err)
+				}
+				reports = append(reports, receipt)
+			}
+		}},
+		{"ListReports", func(t *testing.T) {
+			open, err := admin.ListReports(ctx, "open", 10)
+			if err != nil || len(open) != 2 {
+				t.Fatalf("ListReports() = %+v, %v", open, err)
+			}
+		}},
+		{"ListReports/unauthorized", func(t *testing.T) {
+			_, err := anonymous.ListReports(ctx, "", 0)
+			if !hasStatus(err, http.StatusUnauthorized) {
+				t.Fatalf("ListReports() without token error = %v, want 401", err)
+			}
+		}},
+		{"DismissReport", func(t *testing.T) {
+			report, err := admin.DismissReport(ctx, reports[0].ID)
+			if err != nil || report.Status != "dismissed" {
+				t.Fatalf("DismissReport() = %+v, %v", report, err)
+			}
+		}},
+		{"ConfirmReport", func(t *testing.T) {
+			report, err := admin.ConfirmReport(ctx, reports[1].ID)
+			if err != nil || report.Status != "confirmed" {
+				t.Fatalf("ConfirmReport() = %+v, %v", report, err)
+			}
+		}},
+	}
+
+	// Every client method needs a step
+	covered := make(map[string]bool)
+	for _, step := range steps {
+		method, _, _ := strings.Cut(step.name, "/")
+		covered[method] = true
+	}
+	clientType := reflect.TypeOf(admin)
+	for i := 0; i < clientType.NumMethod(); i++ {
+		if name := clientType.Method(i).Name; !covered[name] {
+			t.Errorf("client method %s has no contract step", name)
+		}
+	}
+
+	for _, step := range steps {
+		ok := t.Run(step.name, func(t *testing.T) {
+			transport.setTest(t)
+			step.run(t)
+		})
+		transport.setTest(t)
+		if !ok {
+			t.FailNow() // later steps depend on earlier ones
+		}
+	}
+}
+
+// hasStatus reports whether err is a client error with the status code
+func hasStatus(err error, status int) bool {
+	var apiErr *client.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
@@ -0,0 +1,529 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/nijaru/nano-link/internal/blocklist"
+	"github.com/nijaru/nano-link/internal/config"
+	appErrors "github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/geoip"
+	"github.com/nijaru/nano-link/internal/handlers"
+	"github.com/nijaru/nano-link/internal/importer"
+	customLogger "github.com/nijaru/nano-link/internal/logger"
+	"github.com/nijaru/nano-link/internal/repository"
+	"github.com/nijaru/nano-link/internal/service"
+)
+
+// exportBatchSize is the number of URLs read per query during an export
+const exportBatchSize = 500
+
+// command is an admin subcommand of the server binary
+type command struct {
+	usage   string
+	summary string
+	run     func(ctx context.Context, env *commandEnv, flags *flag.FlagSet, args []string) error
+}
+
+// commandEnv holds the services the admin commands work through, so that
+// they apply the same rules as the HTTP API
+type commandEnv struct {
+	cfg  *config.Config
+	repo *repository.SQLiteRepository
+	urls *service.URLService
+	keys *service.APIKeyService
+	out  io.Writer
+}
+
+// commands lists the admin subcommands by name
+var commands = map[string]command{
+	"create":  {"create [-code c] [-domain host] [-password p] [-owner o] [-interstitial] [-fallback url] [-tags a,b] [-folder f] [-title t] [-notes n] <url>", "Create a short URL", runCreate},
+	"get":     {"get [-domain host] <code>", "Show a short URL and its visits by country", runGet},
+	"list":    {"list [-limit n] [-sort s] [-asc] [-destination host] [-q text] [-owner o] [-status s] [-health h] [-tag t] [-folder f] [-cursor c]", "List short URLs, newest first", runList},
+	"delete":  {"delete [-domain host] <code>", "Delete a short URL and its visits", runDelete},
+	"disable": {"disable [-domain host] -reason text <code>", "Disable a short URL", runDisable},
+	"enable":  {"enable [-domain host] <code>", "Re-enable a disabled short URL", runEnable},
+	"import":  {"import [-format f] [-map field=column,...] [-domain host] [-dry-run] [-file path]", "Import URLs from nano-link or another shortener (stdin by default)", runImport},
+	"export":  {"export [-file path]", "Export all URLs as JSON lines (stdout by default)", runExport},
+	"stats":   {"stats", "Show usage statistics", runStats},
+	"cleanup": {"cleanup [-dry-run] [-max-age d]", "Delete URLs older than MAX_URL_AGE", runCleanup},
+	"migrate": {"migrate", "Apply pending database migrations", runMigrate},
+	"keys":    {"keys create <name> | keys list | keys revoke <id>", "Manage API keys for the admin endpoints", runKeys},
+}
+
+// runCommand runs an admin subcommand and returns the process exit code
+func runCommand(name string, args []string) int {
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage(os.Stdout)
+		return 0
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		printUsage(os.Stderr)
+		return 2
+	}
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: nano-link %s\n", cmd.usage)
+		flags.PrintDefaults()
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	// Keep stdout for command output; only warnings and errors are logged
+	if err := customLogger.Configure(customLogger.Options{
+		Format: cfg.LogFormat,
+		Level:  "warn",
+		Output: "stderr",
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	env, closeEnv, err := openCommandEnv(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", describeError(err))
+		return 1
+	}
+	defer closeEnv()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd.run(ctx, env, flags, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", describeError(err))
+		return 1
+	}
+	return 0
+}
+
+// openCommandEnv opens the database and builds the services used by the
+// admin commands
+func openCommandEnv(cfg *config.Config) (*commandEnv, func(), error) {
+	repo, err := repository.NewSQLiteRepository(cfg.DBPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	links, err := handlers.NewLinkBuilder(cfg.BaseURL)
+	if err != nil {
+		repo.Close()
+		return nil, nil, err
+	}
+	blocked, err := blocklist.Load(cfg.BlocklistFiles)
+	if err != nil {
+		repo.Close()
+		return nil, nil, err
+	}
+
+	// Events are queued here and delivered by the server
+	webhooks := service.NewWebhookService(repo)
+	events := newWebhookTask(cfg, repo, &webhooks)
+
+	// Country lookups only matter when serving redirects
+	urls, err := newURLService(cfg, repo, &geoip.Resolver{}, links.Host(), blocked, events)
+	if err != nil {
+		repo.Close()
+		return nil, nil, err
+	}
+	keys := service.NewAPIKeyService(repo)
+
+	env := &commandEnv{cfg: cfg, repo: repo, urls: &urls, keys: &keys, out: os.Stdout}
+	return env, func() { repo.Close() }, nil
+}
+
+// printUsage lists the available commands
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: nano-link [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	fmt.Fprintf(w, "  %-8s %s\n", "serve", "Run the HTTP server (default)")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-8s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'nano-link <command> -h' for the flags of a command.")
+}
+
+// describeError returns the message of a client error, or the full error
+func describeError(err error) string {
+	var appErr *appErrors.AppError
+	if errors.As(err, &appErr) && appErr.Type != appErrors.ErrorTypeDatabase && appErr.Type != appErrors.ErrorTypeInternal {
+		return appErr.Message
+	}
+	return err.Error()
+}
+
+// parseArgs parses flags and checks the number of positional arguments
+func parseArgs(flags *flag.FlagSet, args []string, positional int) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != positional {
+		flags.Usage()
+		return fmt.Errorf("expected %d argument(s), got %d", positional, flags.NArg())
+	}
+	return nil
+}
+
+// printJSON writes v as indented JSON
+func (env *commandEnv) printJSON(v interface{}) error {
+	encoder := json.NewEncoder(env.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func runCreate(ctx context.Context, env *commandEnv, flags *flag.FlagSet, args []string) error {
+	var req service.CreateURLRequest
+	flags.StringVar(&req.CustomCode, "code", "", "custom short code")
+	flags.StringVar(&req.Domain, "domain", "", "custom domain of the link")
+	flags.StringVar(&req.Password, "password", "", "password required to follow the link")
+	flags.StringVar(&req.Owner, "owner", "", "owner shown on the interstitial page")
+	flags.BoolVar(&req.Interstitial, "interstitial", false, "show the interstitial page before redirecting")
+	flags.StringVar(&req.FallbackURL, "fallback", "", "web page for non-HTTP destinations")
+	tags := flags.String("tags", "", "comma-separated tags")
+	flags.StringVar(&req.Folder, "folder", "", "folder of the link")
+	flags.StringVar(&req.Title, "title", "", "title of the link")
+	flags.StringVar(&req.Description, "description", "", "description of the link")
+	flags.StringVar(&req.Notes, "notes", "", "private notes about the link")
+	if err := parseArgs(flags, args, 1); err != nil {
+		return err
+	}
+	req.URL = flags.Arg(0)
+	if *tags != "" {
+		req.Tags = strings.Split(*tags, ",")
+	}
+
+	url, err := env.urls.CreateShortURL(ctx, req)
+	if err != nil {
+		return err
+	}
+	return env.printJSON(url)
+}
+
+func runGet(ctx context.Context, env *commandEnv, flags *flag.FlagSet, args []string) error {
+	domain := flags.String("domain", "", "custom domain of the link")
+	if err := parseArgs(flags, args, 1); err != nil {
+		return err
+	}
+
+	url, err := env.urls.GetURL(ctx, *domain, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	countries, err := env.urls.GetCountryVisits(ctx, *domain, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return env.printJSON(map[string]interface{}{
+		"url":       url,
+		"countries": countries,
+	})
+}
+
+func runList(ctx context.Context, env *commandEnv, flags *flag.FlagSet, args []string) error {
+	filter := repository.URLFilter{IncludeProtected: true}
+	flags.IntVar(&filter.Limit, "limit", 10, "number of URLs to show")
+	flags.StringVar(&filter.Health, "health", "", "only show links with this destination health")
+	flags.StringVar(&filter.Sort, "sort", repository.SortCreated, "sort by created, visits or last_visited")
+	flags.BoolVar(&filter.Ascending, "asc", false, "sort in ascending order")
+	flags.StringVar(&filter.Destination, "destination", "", "only show links to this host or its subdomains")
+	flags.StringVar(&filter.Search, "q", "", "only show links whose code or destination contains this text")
+	flags.StringVar(&filter.Owner, "owner", "", "only show links with this owner")
+	flags.StringVar(&filter.Status, "status", "", "only show active or disabled links")
+	flags.StringVar(&filter.Tag, "tag", "", "only show links with this tag")
+	flags.StringVar(&filter.Folder, "folder", "", "only show links in this folder")
+	cursor := flags.String("cursor", "", "continue after a previous page")
+	if err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+
+	page, err := env.urls.GetRecentURLs(ctx, filter, *cursor)
+	if err != nil {
+		return err
+	}
+	return env.printJSON(map[string]interface{}{
+		"urls":        page.URLs,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+func runDelete(ctx context.Context, env *commandEnv, flags *flag.FlagSet, args []string) error {
+	domain := flags.String("domain", "", "custom domain of the link")
+	if err := parseArgs(flags, args, 1); err != nil {
+		return err
+	}
+
+	if err := env.urls.DeleteURL(ctx, *domain, flags.Arg(0)); err != nil {
+		return err
+	}
+	fmt.Fprintf(env.out, "Deleted %s\n", flags.Arg(0))
+	return nil
+}
+
+func runDisable(ctx context.Context, env *commandEnv, flags *flag.FlagSet, args []string) error {
+	domain := flags.String("domain", "", "custom domain of the link")
+	reason := flags.String("reason", "", "why the link is disabled (required)")
+	if err := parseArgs(flags, args, 1); err != nil {
+		return err
+	}
+
+	if err := env.urls.DisableURL(ctx, *domain, flags.Arg(0), *reason); err != nil {
+		return err
+	}
+	fmt.Fprintf(env.out, "Disabled %s\n", flags.Arg(0))
+	return nil
+}
+
+func runEnable(ctx context.Context, env *commandEnv, flags *flag.FlagSet, args []string) error {
+	domain := flags.String("domain", "", "custom domain of the link")
+	if err := parseArgs(flags, args, 1); err != nil {
+		return err
+	}
+
+	if err := env.urls.EnableURL(ctx, *domain, flags.Arg(0)); err != nil {
+		return err
+	}
+	fmt.Fprintf(env.out, "Enabled %s\n", flags.Arg(0))
+	return nil
+}
+
+func runImport(ctx context.Context, env *commandEnv, flags *flag.FlagSet, args []string) error {
+	path := flags.String("file", "", "file to import instead of stdin")
+	format := flags.String("format", importer.FormatNanoLink, "input format: "+strings.Join(importer.Formats, ", "))
+	mapping := flags.String("map", "", "columns to read for CSV and JSON input, e.g. code=slug,url=long_url,created=date,visits=hits,title=name")
+	domain := flags.String("domain", "", "custom domain for links that do not name one")
+	dryRun := flags.Bool("dry-run", false, "validate and report conflicts without storing anything")
+	noExpire := flags.Bool("no-expire", false, "exempt the links from MAX_URL_AGE expiry (always on for yourls and bitly formats)")
+	if err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+
+	columns, err := importer.ParseMapping(*mapping)
+	if err != nil {
+		return err
+	}
+
+	in := io.Reader(os.Stdin)
+	if *path != "" {
+		f, err := os.Open(*path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	source, err := importer.NewSource(in, importer.Options{Format: *format, Mapping: columns, Domain: *domain, NoExpire: *noExpire})
+	if err != nil {
+		return err
+	}
+
+	// Each entry is imported on its own; failures are reported and skipped
+	var imported, conflicts, failed int
+	seen := make(map[string]bool) // domain and code of the entries checked in a dry run
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		entry, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		var entryErr *importer.EntryError
+		if errors.As(err, &entryErr) {
+			fmt.Fprintln(os.Stderr, entryErr)
+			failed++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		key := strings.ToLower(entry.URL.Domain) + "/" + entry.URL.ShortCode
+		switch {
+		case *dryRun && seen[key]:
+			err = service.ErrCodeConflict
+		case *dryRun:
+			_, err = env.urls.PrepareImport(ctx, entry.URL)
+		default:
+			_, err = env.urls.ImportURL(ctx, entry.URL)
+		}
+		seen[key] = true
+
+		switch {
+		case errors.Is(err, service.ErrCodeConflict):
+			fmt.Fprintf(os.Stderr, "entry %d: %s: conflict, code already in use\n", entry.Index, entry.URL.ShortCode)
+			conflicts++
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "entry %d: %s: %s\n", entry.Index, entry.URL.ShortCode, describeError(err))
+			failed++
+		default:
+			imported++
+		}
+	}
+
+	verb := "Imported"
+	if *dryRun {
+		verb = "Would import"
+	}
+	fmt.Fprintf(env.out, "%s %d URL(s), %d conflict(s), %d failed\n", verb, imported, conflicts, failed)
+	if conflicts+failed > 0 {
+		return fmt.Errorf("%d URL(s) could not be imported", conflicts+failed)
+	}
+	return nil
+}
+
+func runExport(ctx context.Context, env *commandEnv, flags *flag.FlagSet, args []string) error {
+	path := flags.String("file", "", "file to write instead of stdout")
+	if err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+
+	out := env.out
+	if *path != "" {
+		f, err := os.Create(*path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	writer := bufio.NewWriter(out)
+	encoder := json.NewEncoder(writer)
+	var afterID int64
+	for {
+		urls, err := env.urls.ListURLs(ctx, afterID, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, url := range urls {
+			if err := encoder.Encode(service.NewExportedURL(url)); err != nil {
+				return err
+			}
+			afterID = url.ID
+		}
+		if len(urls) < exportBatchSize {
+			break
+		}
+	}
+	return writer.Flush()
+}
+
+func runStats(ctx context.Context, env *commandEnv, flags *flag.FlagSet, args []string) error {
+	if err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+
+	stats, err := env.urls.GetStats(ctx)
+	if err != nil {
+		return err
+	}
+	return env.printJSON(stats)
+}
+
+func runCleanup(ctx context.Context, env *commandEnv, flags *flag.FlagSet, args []string) error {
+	dryRun := flags.Bool("dry-run", false, "only count the URLs that would be deleted")
+	maxAge := flags.Duration("max-age", env.cfg.MaxURLAge, "delete URLs older than this")
+	if err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+
+	if *dryRun {
+		count, err := env.urls.CountExpiredURLs(ctx, *maxAge)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(env.out, "Would delete %d URL(s) older than %s\n", count, *maxAge)
+		return nil
+	}
+
+	// Expired links are published like in the cleanup task
+	deleted, err := env.urls.ExpireOldURLs(ctx, *maxAge)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.out, "Deleted %d URL(s) older than %s\n", deleted, *maxAge)
+	return nil
+}
+
+func runMigrate(ctx context.Context, env *commandEnv, flags *flag.FlagSet, args []string) error {
+	if err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+
+	// Pending migrations were applied when the database was opened
+	version, err := env.repo.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.out, "Database schema is at version %d\n", version)
+	return nil
+}
+
+func runKeys(ctx context.Context, env *commandEnv, flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	switch action := flags.Arg(0); {
+	case action == "create" && flags.NArg() == 2:
+		key, secret, err := env.keys.CreateKey(ctx, flags.Arg(1))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(env.out, "Created API key %d (%s)\n", key.ID, key.Name)
+		fmt.Fprintf(env.out, "Secret: %s\n", secret)
+		fmt.Fprintln(env.out, "Store the secret now; it cannot be shown again.")
+		return nil
+
+	case action == "list" && flags.NArg() == 1:
+		keys, err := env.keys.ListKeys(ctx)
+		if err != nil {
+			return err
+		}
+		return env.printJSON(keys)
+
+	case action == "revoke" && flags.NArg() == 2:
+		id, err := strconv.ParseInt(flags.Arg(1), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid key ID %q", flags.Arg(1))
+		}
+		if err := env.keys.RevokeKey(ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(env.out, "Revoked API key %d\n", id)
+		return nil
+	}
+
+	flags.Usage()
+	return errors.New("expected keys create <name>, keys list or keys revoke <id>")
+}
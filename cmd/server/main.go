@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -13,18 +14,34 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/helmet"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 
+	"github.com/nijaru/nano-link/internal/blocklist"
 	"github.com/nijaru/nano-link/internal/config"
+	"github.com/nijaru/nano-link/internal/geoip"
 	"github.com/nijaru/nano-link/internal/handlers"
 	customLogger "github.com/nijaru/nano-link/internal/logger"
+	"github.com/nijaru/nano-link/internal/metrics"
 	"github.com/nijaru/nano-link/internal/middleware"
+	"github.com/nijaru/nano-link/internal/probes"
+	"github.com/nijaru/nano-link/internal/qr"
 	"github.com/nijaru/nano-link/internal/repository"
 	"github.com/nijaru/nano-link/internal/service"
 	"github.com/nijaru/nano-link/internal/tasks"
+	"github.com/nijaru/nano-link/internal/tracing"
 )
 
 func main() {
 	// Initialize logger
 	customLogger.Init()
+
+	// Without a command the binary runs the server
+	if len(os.Args) > 1 && os.Args[1] != "serve" {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+	serve()
+}
+
+// serve runs the HTTP server until it receives SIGINT or SIGTERM
+func serve() {
 	customLogger.Info("Starting nano-link service")
 
 	// Load configuration
@@ -33,8 +50,35 @@ func main() {
 		customLogger.Error(err, "Failed to load configuration")
 		os.Exit(1)
 	}
+
+	// Apply the logging configuration
+	if err := customLogger.Configure(customLogger.Options{
+		Format: cfg.LogFormat,
+		Level:  cfg.LogLevel,
+		Output: cfg.LogOutput,
+	}); err != nil {
+		customLogger.Error(err, "Failed to configure logging")
+		os.Exit(1)
+	}
 	customLogger.Info("Configuration loaded successfully")
 
+	proxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		customLogger.Error(err, "Invalid trusted proxy configuration")
+		os.Exit(1)
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingEndpoint,
+		ServiceName: cfg.TracingServiceName,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		customLogger.Error(err, "Failed to initialize tracing")
+		os.Exit(1)
+	}
+
 	// Setup Fiber with optimized settings
 	app := fiber.New(fiber.Config{
 		ErrorHandler:          handlers.ErrorHandler,
@@ -43,16 +87,27 @@ func main() {
 		ReadTimeout:           5 * time.Second,
 		WriteTimeout:          10 * time.Second,
 		IdleTimeout:           120 * time.Second,
+
+		// Only believe X-Forwarded-Proto/Host from trusted proxies
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.TrustedProxies,
 	})
 
 	// Apply middleware
-	app.Use(recover.New())      // Recover from panics
-	app.Use(helmet.New())       // Security headers
-	app.Use(compress.New())     // Compression
+	app.Use(recover.New())          // Recover from panics
+	app.Use(middleware.RequestID()) // Request ID for responses and log lines
+	if cfg.AccessLog {
+		app.Use(middleware.AccessLog()) // One log line per request
+	}
+	app.Use(helmet.New())   // Security headers
+	app.Use(compress.New()) // Compression
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowMethods: "GET,POST,OPTIONS",
+		AllowMethods: "GET,POST,PUT,PATCH,DELETE,OPTIONS",
 	}))
+	app.Use(middleware.Tracing()) // Server span per request
+	app.Use(middleware.Metrics()) // Request counts and latency per route
+	app.Use(middleware.RealIP(proxies))
 	app.Use(middleware.RateLimit(cfg.RateLimit, cfg.RateLimitWindow))
 
 	// Initialize repository with context
@@ -65,24 +120,118 @@ func main() {
 	}
 	customLogger.Info("Repository initialized successfully")
 
+	// Open the GeoIP database if configured; lookups are skipped without one
+	geo, err := geoip.Open(cfg.GeoIPDBPath)
+	if err != nil {
+		customLogger.Error(err, "Failed to open GeoIP database, continuing without geo lookups", map[string]interface{}{
+			"path": cfg.GeoIPDBPath,
+		})
+		geo = &geoip.Resolver{}
+	} else if geo.Enabled() {
+		customLogger.Info("GeoIP database loaded", map[string]interface{}{
+			"path": cfg.GeoIPDBPath,
+		})
+	}
+
+	links, err := handlers.NewLinkBuilder(cfg.BaseURL)
+	if err != nil {
+		customLogger.Error(err, "Invalid base URL")
+		os.Exit(1)
+	}
+
+	// Load the blocklist; without files nothing is blocked
+	blocked, err := blocklist.Load(cfg.BlocklistFiles)
+	if err != nil {
+		customLogger.Error(err, "Failed to load blocklist")
+		os.Exit(1)
+	}
+
+	// Link events are queued for webhooks by the delivery task
+	webhookService := service.NewWebhookService(repo)
+	webhookHandler := handlers.NewWebhookHandler(&webhookService)
+	webhookTask := newWebhookTask(cfg, repo, &webhookService)
+
 	// Initialize service and handlers
-	urlService := service.NewURLService(repo)
-	urlHandler := handlers.NewURLHandler(&urlService)
+	urlService, err := newURLService(cfg, repo, geo, links.Host(), blocked, webhookTask)
+	if err != nil {
+		customLogger.Error(err, "Failed to initialize URL service")
+		os.Exit(1)
+	}
+	urlHandler := handlers.NewURLHandler(&urlService, links)
+	collectionService := service.NewCollectionService(repo, repo)
+	collectionHandler := handlers.NewCollectionHandler(&collectionService, links)
+	domainService := service.NewDomainService(repo, links.Host())
+	domainHandler := handlers.NewDomainHandler(&domainService)
+	tagService := service.NewTagService(repo, repo, webhookTask)
+	tagHandler := handlers.NewTagHandler(&tagService, &urlService, links)
+	reportService := service.NewReportService(repo, repo, cfg.ReportThreshold, webhookTask)
+	reportHandler := handlers.NewReportHandler(&reportService, &urlService)
+	apiKeyService := service.NewAPIKeyService(repo)
+	qrGenerator, err := qr.NewGenerator(cfg.QRLogoFile, cfg.QRCacheSize)
+	if err != nil {
+		customLogger.Error(err, "Failed to initialize QR code generator")
+		os.Exit(1)
+	}
+	qrHandler := handlers.NewQRHandler(&urlService, qrGenerator, links)
 
 	// Start cleanup task
-	cleanupTask := tasks.NewCleanupTask(repo, cfg.CleanupInterval, cfg.MaxURLAge)
+	cleanupTask := tasks.NewCleanupTask(&urlService, cfg.CleanupInterval, cfg.MaxURLAge)
 	cleanupTask.Start()
 
+	// Send queued webhook deliveries
+	webhookTask.Start()
+
+	// Start destination health checks if enabled
+	var healthTask *tasks.HealthCheckTask
+	if cfg.HealthCheckInterval > 0 {
+		healthTask = tasks.NewHealthCheckTask(repo, &urlService, tasks.HealthCheckConfig{
+			Interval:    cfg.HealthCheckInterval,
+			Concurrency: cfg.HealthCheckConcurrency,
+			HostDelay:   cfg.HealthCheckHostDelay,
+			WebhookURL:  cfg.HealthWebhookURL,
+		})
+		healthTask.Start()
+	}
+
+	// Fetch the page metadata of new links if enabled
+	var metadataTask *tasks.MetadataTask
+	if cfg.MetadataFetchInterval > 0 {
+		metadataTask = tasks.NewMetadataTask(repo, &urlService, cfg.MetadataFetchInterval)
+		metadataTask.Start()
+	}
+
+	// Watch the blocklist for changes
+	var blocklistTask *tasks.BlocklistTask
+	if blocked.Enabled() {
+		blocklistTask = tasks.NewBlocklistTask(blocked, &urlService, cfg.BlocklistPollInterval)
+		blocklistTask.Start()
+	}
+
+	// Liveness follows the task heartbeats; readiness checks the database and disk
+	prober := probes.NewProber(repo, cfg.DBPath, uint64(cfg.ReadyMinFreeDiskMB)<<20)
+	prober.AddHeartbeat(cleanupTask.Heartbeat())
+	prober.AddHeartbeat(webhookTask.Heartbeat())
+	if healthTask != nil {
+		prober.AddHeartbeat(healthTask.Heartbeat())
+	}
+	if blocklistTask != nil {
+		prober.AddHeartbeat(blocklistTask.Heartbeat())
+	}
+	if metadataTask != nil {
+		prober.AddHeartbeat(metadataTask.Heartbeat())
+	}
+	probeHandler := handlers.NewProbeHandler(prober)
+
 	// Setup routes
-	setupRoutes(app, urlHandler)
+	setupRoutes(app, middleware.AdminAuth(cfg.AdminToken, &apiKeyService), urlHandler, tagHandler, qrHandler, collectionHandler, domainHandler, reportHandler, webhookHandler, probeHandler)
 
 	// Start server in a goroutine
 	go func() {
 		customLogger.Info("Starting server", map[string]interface{}{
-			"port": cfg.Port,
+			"port":     cfg.Port,
 			"base_url": cfg.BaseURL,
 		})
-		
+
 		if err := app.Listen(":" + cfg.Port); err != nil {
 			customLogger.Error(err, "Server failed to start")
 			os.Exit(1)
@@ -96,10 +245,30 @@ func main() {
 
 	customLogger.Info("Shutting down server...")
 
+	// Fail readiness first so load balancers stop routing new requests here
+	prober.SetShuttingDown()
+	if cfg.ShutdownDelay > 0 {
+		customLogger.Info("Waiting before closing the server", map[string]interface{}{
+			"delay": cfg.ShutdownDelay.String(),
+		})
+		time.Sleep(cfg.ShutdownDelay)
+	}
+
 	// Stop the cleanup task
 	cleanupTask.Stop()
 	customLogger.Info("Cleanup task stopped")
 
+	if blocklistTask != nil {
+		blocklistTask.Stop()
+	}
+	if healthTask != nil {
+		healthTask.Stop()
+	}
+	if metadataTask != nil {
+		metadataTask.Stop()
+	}
+	webhookTask.Stop()
+
 	// Shutdown server with timeout
 	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -113,20 +282,114 @@ func main() {
 		customLogger.Error(err, "Error closing repository")
 	}
 
+	if err := geo.Close(); err != nil {
+		customLogger.Error(err, "Error closing GeoIP database")
+	}
+
+	// Flush buffered spans
+	if err := shutdownTracing(ctx); err != nil {
+		customLogger.Error(err, "Error shutting down tracing")
+	}
+
 	customLogger.Info("Server gracefully stopped")
 }
 
+// newURLService creates the URL service with the configured link rules, shared
+// by the server and the admin commands
+func newURLService(cfg *config.Config, repo *repository.SQLiteRepository, geo *geoip.Resolver, host string, blocked *blocklist.Blocklist, events service.EventPublisher) (service.URLService, error) {
+	allowlist, err := service.ParseDestinationAllowlist(cfg.DestinationAllowlist)
+	if err != nil {
+		return service.URLService{}, fmt.Errorf("invalid destination allowlist: %w", err)
+	}
+
+	return service.NewURLService(
+		repo,
+		service.WithGeoIP(geo),
+		service.WithPasswordThrottle(cfg.PasswordMaxAttempts, cfg.PasswordLockout),
+		service.WithInterstitial(service.InterstitialConfig{
+			Always:    cfg.InterstitialAlways,
+			Countdown: cfg.InterstitialCountdown,
+			Warning:   cfg.InterstitialWarning,
+		}),
+		service.WithAllowedSchemes(cfg.AllowedSchemes),
+		service.WithDomains(repo),
+		service.WithDestinationAllowlist(allowlist),
+		service.WithDefaultHost(host),
+		service.WithShortenerExpansion(cfg.FollowShorteners),
+		service.WithMetadataCapture(cfg.MetadataFetchInterval > 0),
+		service.WithBlocklist(blocked),
+		service.WithEvents(events),
+	), nil
+}
+
+// newWebhookTask creates the task that queues link events for webhooks and
+// sends the deliveries
+func newWebhookTask(cfg *config.Config, repo *repository.SQLiteRepository, webhooks *service.WebhookService) *tasks.WebhookTask {
+	return tasks.NewWebhookTask(repo, webhooks, tasks.WebhookConfig{
+		PollInterval: cfg.WebhookPollInterval,
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		Retention:    cfg.WebhookRetention,
+	})
+}
+
 // setupRoutes defines all the API routes
-func setupRoutes(app *fiber.App, handler *handlers.URLHandler) {
+func setupRoutes(app *fiber.App, adminAuth fiber.Handler, handler *handlers.URLHandler, tags *handlers.TagHandler, qrCodes *handlers.QRHandler, collections *handlers.CollectionHandler, domains *handlers.DomainHandler, reports *handlers.ReportHandler, webhooks *handlers.WebhookHandler, probes *handlers.ProbeHandler) {
 	// API routes
 	api := app.Group("/api")
 	{
 		api.Post("/shorten", handler.CreateShortURL)
 		api.Get("/urls/:code", handler.GetURLInfo)
+		api.Patch("/urls/:code", adminAuth, handler.UpdateURL)
+		api.Get("/urls/:code/countries", handler.GetCountryVisits)
+		api.Get("/urls/:code/preview", handler.GetPreview)
+		api.Get("/urls/:code/qr", qrCodes.GetQRCode)
 		api.Get("/urls", handler.GetRecentURLs)
 		api.Get("/stats", handler.GetStats)
+
+		api.Post("/urls/:code/tags", adminAuth, tags.AddTags)
+		api.Delete("/urls/:code/tags/:tag", adminAuth, tags.RemoveTag)
+		api.Put("/urls/:code/folder", adminAuth, tags.SetFolder)
+		api.Delete("/urls/:code/folder", adminAuth, tags.ClearFolder)
+		api.Get("/tags", tags.ListTags)
+		api.Get("/tags/:tag/stats", tags.GetTagStats)
+		api.Get("/folders", tags.ListFolders)
+
+		// Collections are public; changing them takes the admin token
+		api.Post("/collections", adminAuth, collections.CreateCollection)
+		api.Get("/collections/:slug", collections.GetCollection)
+		api.Delete("/collections/:slug", adminAuth, collections.DeleteCollection)
+		api.Post("/collections/:slug/items", adminAuth, collections.AddItem)
+		api.Put("/collections/:slug/items/order", adminAuth, collections.ReorderItems)
+		api.Delete("/collections/:slug/items/:item", adminAuth, collections.RemoveItem)
+
+		api.Post("/domains", adminAuth, domains.CreateDomain)
+		api.Get("/domains", domains.ListDomains)
+		api.Delete("/domains/:host", adminAuth, domains.DeleteDomain)
+
+		api.Post("/report/:code", reports.Report)
+
+		api.Get("/openapi.json", handlers.OpenAPISpec)
+		api.Get("/docs", handlers.APIDocs)
+	}
+
+	// Admin routes, guarded by ADMIN_TOKEN or an API key
+	admin := app.Group("/api/admin", adminAuth)
+	{
+		admin.Get("/reports", reports.ListReports)
+		admin.Post("/reports/:id/dismiss", reports.DismissReport)
+		admin.Post("/reports/:id/confirm", reports.ConfirmReport)
+
+		admin.Post("/webhooks", webhooks.CreateWebhook)
+		admin.Get("/webhooks", webhooks.ListWebhooks)
+		admin.Get("/webhooks/deliveries", webhooks.ListDeliveries)
+		admin.Post("/webhooks/deliveries/:id/retry", webhooks.RetryDelivery)
+		admin.Delete("/webhooks/:id", webhooks.DeleteWebhook)
+		admin.Get("/webhooks/:id/deliveries", webhooks.ListWebhookDeliveries)
 	}
 
+	// Prometheus metrics
+	app.Get("/metrics", metrics.Handler())
+
 	// Status endpoint
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
@@ -135,8 +398,18 @@ func setupRoutes(app *fiber.App, handler *handlers.URLHandler) {
 		})
 	})
 
+	// Kubernetes-style probes with per-check results
+	app.Get("/livez", probes.Livez)
+	app.Get("/readyz", probes.Readyz)
+
+	// Collection pages, registered before the redirect route so "/@slug" is not taken as a code
+	app.Get("/@:slug", collections.HandlePage)
+	app.Get("/@:slug/:item", collections.HandleItemClick)
+
 	// Main redirect route
 	app.Get("/:code", handler.HandleRedirect)
+	app.Get("/:code/preview", handler.HandlePreview)
+	app.Post("/:code", handler.UnlockRedirect)
 
 	// Serve static files with caching
 	app.Static("/", "./static", fiber.Static{
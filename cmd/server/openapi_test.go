@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nijaru/nano-link/internal/openapi"
+)
+
+// specParamRegex matches the parameters of an OpenAPI path template
+var specParamRegex = regexp.MustCompile(`\{[^/}]+\}`)
+
+// routeParamRegex matches the parameters of a Fiber route
+var routeParamRegex = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// apiSpec is the decoded OpenAPI document with a pattern for each path
+type apiSpec struct {
+	doc   map[string]any
+	paths []specPath
+}
+
+// specPath is a path template of the document
+type specPath struct {
+	template string
+	params   int
+	pattern  *regexp.Regexp
+	item     map[string]any
+}
+
+// loadSpec decodes the embedded OpenAPI document
+func loadSpec(t *testing.T) *apiSpec {
+	t.Helper()
+
+	var doc map[string]any
+	if err := json.Unmarshal(openapi.Spec, &doc); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+
+	spec := &apiSpec{doc: doc}
+	paths, _ := doc["paths"].(map[string]any)
+	for template, item := range paths {
+		parts := specParamRegex.Split(template, -1)
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		spec.paths = append(spec.paths, specPath{
+			template: template,
+			params:   len(parts) - 1,
+			pattern:  regexp.MustCompile("^" + strings.Join(parts, "[^/]+") + "$"),
+			item:     item.(map[string]any),
+		})
+	}
+
+	// Literal paths win over templates, so /api/urls/{code}/qr is not taken as /{code}/preview
+	sort.Slice(spec.paths, func(i, j int) bool {
+		a, b := spec.paths[i], spec.paths[j]
+		if a.params != b.params {
+			return a.params < b.params
+		}
+		if len(a.template) != len(b.template) {
+			return len(a.template) > len(b.template)
+		}
+		return a.template < b.template
+	})
+	return spec
+}
+
+// operation finds the documented operation serving a request
+func (s *apiSpec) operation(method, path string) (map[string]any, error) {
+	for _, p := range s.paths {
+		if !p.pattern.MatchString(path) {
+			continue
+		}
+		if op, ok := p.item[strings.ToLower(method)].(map[string]any); ok {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("%s %s is not documented", method, path)
+}
+
+// checkResponse validates a response against the documented operation
+func (s *apiSpec) checkResponse(method, path string, status int, contentType string, body []byte) error {
+	op, err := s.operation(method, path)
+	if err != nil {
+		return err
+	}
+
+	responses, _ := op["responses"].(map[string]any)
+	response, ok := responses[strconv.Itoa(status)].(map[string]any)
+	if !ok {
+		if response, ok = responses["default"].(map[string]any); !ok {
+			return fmt.Errorf("status %d is not documented", status)
+		}
+	}
+	response = s.resolve(response)
+
+	content, _ := response["content"].(map[string]any)
+	if len(content) == 0 {
+		if len(body) > 0 {
+			return fmt.Errorf("status %d is documented without a body", status)
+		}
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+	media, ok := content[mediaType].(map[string]any)
+	if !ok {
+		return fmt.Errorf("content type %s is not documented for status %d", mediaType, status)
+	}
+	if mediaType != "application/json" {
+		return nil
+	}
+	schema, ok := media["schema"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return s.checkValue(schema, value, "body")
+}
+
+// resolve follows a local $ref
+func (s *apiSpec) resolve(node map[string]any) map[string]any {
+	for {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			return node
+		}
+		var target any = s.doc
+		for _, name := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			object, _ := target.(map[string]any)
+			target = object[name]
+		}
+		resolved, ok := target.(map[string]any)
+		if !ok {
+			panic("unresolved reference " + ref)
+		}
+		node = resolved
+	}
+}
+
+// checkValue validates a decoded JSON value against a schema
+func (s *apiSpec) checkValue(schema map[string]any, value any, at string) error {
+	schema = s.resolve(schema)
+	if value == nil {
+		if schema["nullable"] == true {
+			return nil
+		}
+		return fmt.Errorf("%s: null is not documented", at)
+	}
+
+	if values, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range values {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", at, value, values)
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %T", at, value)
+		}
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing required property %q", at, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := properties[name].(map[string]any)
+			if !ok {
+				switch additional := schema["additionalProperties"].(type) {
+				case map[string]any:
+					property = additional
+				case bool:
+					if additional {
+						continue
+					}
+				}
+			}
+			if property == nil {
+				return fmt.Errorf("%s: property %q is not documented", at, name)
+			}
+			if err := s.checkValue(property, object[name], at+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an array, got %T", at, value)
+		}
+		itemSchema, _ := schema["items"].(map[string]any)
+		for i, item := range items {
+			if itemSchema == nil {
+				break
+			}
+			if err := s.checkValue(itemSchema, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected a string, got %T", at, value)
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				return fmt.Errorf("%s: %q is not a date-time", at, str)
+			}
+		}
+		if maxLength, ok := schema["maxLength"].(float64); ok && len([]rune(str)) > int(maxLength) {
+			return fmt.Errorf("%s: longer than %v characters", at, maxLength)
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: expected an integer, got %T", at, value)
+		}
+		if _, err := number.Int64(); err != nil {
+			return fmt.Errorf("%s: %s is not an integer", at, number)
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return fmt.Errorf("%s: expected a number, got %T", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean, got %T", at, value)
+		}
+	}
+	return nil
+}
+
+// TestSpecCoversRoutes checks that every route has an operation in the
+// document and every operation has a route
+func TestSpecCoversRoutes(t *testing.T) {
+	spec := loadSpec(t)
+	app := newTestServer(t).app
+
+	routes := make(map[string]bool)
+	for _, route := range app.GetRoutes(true) {
+		switch route.Method {
+		case "GET", "POST", "PUT", "PATCH", "DELETE":
+		default:
+			continue
+		}
+		if strings.Contains(route.Path, "*") {
+			continue // static files
+		}
+		path := routeParamRegex.ReplaceAllString(route.Path, "{$1}")
+		routes[route.Method+" "+path] = true
+	}
+
+	operations := make(map[string]bool)
+	for _, p := range spec.paths {
+		for method := range p.item {
+			switch method {
+			case "get", "post", "put", "patch", "delete":
+			default:
+				continue
+			}
+			operations[strings.ToUpper(method)+" "+p.template] = true
+		}
+	}
+
+	for route := range routes {
+		if !operations[route] {
+			t.Errorf("route %s is not documented", route)
+		}
+	}
+	for operation := range operations {
+		if !routes[operation] {
+			t.Errorf("operation %s has no route", operation)
+		}
+	}
+}
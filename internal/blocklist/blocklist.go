@@ -0,0 +1,181 @@
+package blocklist
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Blocklist holds domain and URL patterns loaded from files. Each line is
+// either a hosts-file entry ("0.0.0.0 evil.example"), a domain, which also
+// blocks its subdomains, or a URL prefix ("evil.example/login"). Blank lines
+// and "#" comments are ignored.
+type Blocklist struct {
+	paths []string
+
+	mu       sync.RWMutex
+	domains  map[string]bool
+	prefixes []string // lowercase "host/path" prefixes
+	modTimes map[string]time.Time
+}
+
+// Load reads the blocklist files at paths. An empty list blocks nothing.
+func Load(paths []string) (*Blocklist, error) {
+	b := &Blocklist{paths: paths}
+	if err := b.Reload(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Enabled reports whether any blocklist files are configured
+func (b *Blocklist) Enabled() bool {
+	return b != nil && len(b.paths) > 0
+}
+
+// Reload re-reads every file. On error the previous patterns are kept.
+func (b *Blocklist) Reload() error {
+	domains := make(map[string]bool)
+	var prefixes []string
+	modTimes := make(map[string]time.Time, len(b.paths))
+
+	for _, path := range b.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to read blocklist: %w", err)
+		}
+		modTimes[path] = info.ModTime()
+
+		if err := parseFile(path, domains, &prefixes); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	b.domains = domains
+	b.prefixes = prefixes
+	b.modTimes = modTimes
+	b.mu.Unlock()
+	return nil
+}
+
+// Changed reports whether any file was modified, created or removed since
+// the last successful load
+func (b *Blocklist) Changed() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, path := range b.paths {
+		info, err := os.Stat(path)
+		modTime, loaded := b.modTimes[path]
+		if (err == nil) != loaded || (err == nil && !info.ModTime().Equal(modTime)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Size returns the number of loaded patterns
+func (b *Blocklist) Size() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.domains) + len(b.prefixes)
+}
+
+// Match returns the pattern blocking urlStr, if any. Only destinations with
+// a host are checked.
+func (b *Blocklist) Match(urlStr string) (string, bool) {
+	if b == nil {
+		return "", false
+	}
+
+	u, err := url.Parse(urlStr)
+	if err != nil || u.Hostname() == "" {
+		return "", false
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	// Check the host and each parent domain
+	for domain := host; domain != ""; {
+		if b.domains[domain] {
+			return domain, true
+		}
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			break
+		}
+		domain = domain[dot+1:]
+	}
+
+	target := host + strings.ToLower(u.EscapedPath())
+	for _, prefix := range b.prefixes {
+		if strings.HasPrefix(target, prefix) {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// parseFile adds the patterns in one file
+func parseFile(path string, domains map[string]bool, prefixes *[]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read blocklist: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(strings.ToLower(line))
+		if len(fields) == 0 {
+			continue
+		}
+
+		// Hosts-file format: an address followed by one or more names
+		if len(fields) > 1 && net.ParseIP(fields[0]) != nil {
+			for _, name := range fields[1:] {
+				if isDomain(name) {
+					domains[strings.TrimSuffix(name, ".")] = true
+				}
+			}
+			continue
+		}
+
+		pattern := fields[0]
+		if i := strings.Index(pattern, "://"); i >= 0 {
+			pattern = pattern[i+3:]
+		}
+		pattern = strings.TrimPrefix(strings.TrimPrefix(pattern, "*."), ".")
+
+		if strings.Contains(pattern, "/") {
+			*prefixes = append(*prefixes, pattern)
+		} else if isDomain(pattern) {
+			domains[strings.TrimSuffix(pattern, ".")] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read blocklist %s: %w", path, err)
+	}
+	return nil
+}
+
+// isDomain reports whether name looks like a domain rather than a local
+// alias such as "localhost" or an address
+func isDomain(name string) bool {
+	return strings.Contains(strings.Trim(name, "."), ".") && net.ParseIP(name) == nil
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -9,15 +12,91 @@ import (
 	"github.com/nijaru/nano-link/internal/errors"
 )
 
+// schemeRegex matches a bare URL scheme name
+var schemeRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*$`)
+
 // Config holds all application configuration loaded from environment variables
 type Config struct {
 	Port            string        `envconfig:"PORT" default:"3000"`
 	DBPath          string        `envconfig:"DB_PATH" default:"urls.db"`
-	BaseURL         string        `envconfig:"BASE_URL" default:"http://localhost:3000"`
+	BaseURL         string        `envconfig:"BASE_URL"`
 	RateLimit       int           `envconfig:"RATE_LIMIT" default:"100"`
 	RateLimitWindow time.Duration `envconfig:"RATE_LIMIT_WINDOW" default:"1m"`
 	CleanupInterval time.Duration `envconfig:"CLEANUP_INTERVAL" default:"24h"`
 	MaxURLAge       time.Duration `envconfig:"MAX_URL_AGE" default:"720h"` // 30 days
+	GeoIPDBPath     string        `envconfig:"GEOIP_DB_PATH"`              // Optional MaxMind MMDB file
+
+	// Brute-force throttling for password-protected links
+	PasswordMaxAttempts int           `envconfig:"PASSWORD_MAX_ATTEMPTS" default:"5"`
+	PasswordLockout     time.Duration `envconfig:"PASSWORD_LOCKOUT" default:"15m"`
+
+	// Interstitial page shown before redirecting
+	InterstitialAlways    bool   `envconfig:"INTERSTITIAL_ALWAYS" default:"false"`
+	InterstitialCountdown int    `envconfig:"INTERSTITIAL_COUNTDOWN" default:"5"` // seconds
+	InterstitialWarning   string `envconfig:"INTERSTITIAL_WARNING"`               // empty uses the built-in text
+
+	// Non-HTTP destination schemes such as mailto, tel or myapp
+	AllowedSchemes []string `envconfig:"ALLOWED_SCHEMES"`
+
+	// Proxy IPs or CIDR ranges whose X-Forwarded-* headers are trusted
+	TrustedProxies []string `envconfig:"TRUSTED_PROXIES"`
+
+	// Intranet hosts ("wiki.corp", "*.corp.example.com") and networks
+	// ("10.0.0.0/8") that destinations may point at despite the private address checks
+	DestinationAllowlist []string `envconfig:"DESTINATION_ALLOWLIST"`
+
+	// Expand links on known shorteners such as bit.ly when creating short URLs
+	FollowShorteners bool `envconfig:"FOLLOW_SHORTENERS" default:"false"`
+
+	// Local blocklists of malicious domains and URLs, reloaded on SIGHUP or change
+	BlocklistFiles        []string      `envconfig:"BLOCKLIST_FILES"`
+	BlocklistPollInterval time.Duration `envconfig:"BLOCKLIST_POLL_INTERVAL" default:"30s"`
+
+	// Background destination health checks; disabled when the interval is 0
+	HealthCheckInterval    time.Duration `envconfig:"HEALTH_CHECK_INTERVAL" default:"0"`
+	HealthCheckConcurrency int           `envconfig:"HEALTH_CHECK_CONCURRENCY" default:"4"`
+	HealthCheckHostDelay   time.Duration `envconfig:"HEALTH_CHECK_HOST_DELAY" default:"1s"`
+	HealthWebhookURL       string        `envconfig:"HEALTH_WEBHOOK_URL"`
+
+	// Background fetch of the title and description of new links' pages;
+	// disabled when the interval is 0
+	MetadataFetchInterval time.Duration `envconfig:"METADATA_FETCH_INTERVAL" default:"0"`
+
+	// Webhook deliveries: time between checks of the queue, attempts before
+	// a delivery is dead, and how long finished deliveries are kept
+	WebhookPollInterval time.Duration `envconfig:"WEBHOOK_POLL_INTERVAL" default:"5s"`
+	WebhookMaxAttempts  int           `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	WebhookRetention    time.Duration `envconfig:"WEBHOOK_RETENTION" default:"168h"`
+
+	// Bearer token for the admin endpoints. API keys are accepted as well, so
+	// the endpoints stay usable with keys alone when this is empty.
+	AdminToken string `envconfig:"ADMIN_TOKEN"`
+
+	// QR codes: optional PNG or JPEG logo drawn in the center, and the number
+	// of generated images kept in memory
+	QRLogoFile  string `envconfig:"QR_LOGO_FILE"`
+	QRCacheSize int    `envconfig:"QR_CACHE_SIZE" default:"256"`
+
+	// Distinct reporters that disable a link pending review; 0 never disables
+	ReportThreshold int `envconfig:"REPORT_THRESHOLD" default:"3"`
+
+	// OpenTelemetry tracing: "none", "otlp" (OTLP over HTTP) or "stdout"
+	TracingExporter    string  `envconfig:"TRACING_EXPORTER" default:"none"`
+	TracingEndpoint    string  `envconfig:"TRACING_ENDPOINT" default:"http://localhost:4318"`
+	TracingServiceName string  `envconfig:"TRACING_SERVICE_NAME" default:"nano-link"`
+	TracingSampleRatio float64 `envconfig:"TRACING_SAMPLE_RATIO" default:"1"`
+
+	// Logging: format is "console" or "json"; output is stdout, stderr or a file path
+	LogFormat string `envconfig:"LOG_FORMAT" default:"console"`
+	LogLevel  string `envconfig:"LOG_LEVEL" default:"info"`
+	LogOutput string `envconfig:"LOG_OUTPUT" default:"stdout"`
+	AccessLog bool   `envconfig:"ACCESS_LOG" default:"true"`
+
+	// Readiness fails below this much free space in the database directory
+	ReadyMinFreeDiskMB int `envconfig:"READY_MIN_FREE_DISK_MB" default:"100"`
+
+	// Time between readiness failing and the server closing on shutdown
+	ShutdownDelay time.Duration `envconfig:"SHUTDOWN_DELAY" default:"0s"`
 }
 
 // Validate performs validation checks on the configuration
@@ -28,6 +107,11 @@ func (c *Config) Validate() error {
 	if c.DBPath == "" {
 		return errors.NewValidationError("database path cannot be empty")
 	}
+	if c.BaseURL != "" {
+		if u, err := url.Parse(c.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.NewValidationError("base URL must be an absolute http or https URL")
+		}
+	}
 	if c.RateLimit <= 0 {
 		return errors.NewValidationError("rate limit must be positive")
 	}
@@ -40,6 +124,74 @@ func (c *Config) Validate() error {
 	if c.MaxURLAge <= 0 {
 		return errors.NewValidationError("max URL age must be positive")
 	}
+	if c.PasswordMaxAttempts <= 0 {
+		return errors.NewValidationError("password max attempts must be positive")
+	}
+	if c.PasswordLockout <= 0 {
+		return errors.NewValidationError("password lockout must be positive")
+	}
+	if c.InterstitialCountdown < 0 {
+		return errors.NewValidationError("interstitial countdown cannot be negative")
+	}
+	if c.BlocklistPollInterval <= 0 {
+		return errors.NewValidationError("blocklist poll interval must be positive")
+	}
+	if c.HealthCheckInterval < 0 {
+		return errors.NewValidationError("health check interval cannot be negative")
+	}
+	if c.HealthCheckConcurrency <= 0 {
+		return errors.NewValidationError("health check concurrency must be positive")
+	}
+	if c.HealthCheckHostDelay < 0 {
+		return errors.NewValidationError("health check host delay cannot be negative")
+	}
+	if c.MetadataFetchInterval < 0 {
+		return errors.NewValidationError("metadata fetch interval cannot be negative")
+	}
+	if c.WebhookPollInterval <= 0 {
+		return errors.NewValidationError("webhook poll interval must be positive")
+	}
+	if c.WebhookMaxAttempts <= 0 {
+		return errors.NewValidationError("webhook max attempts must be positive")
+	}
+	if c.WebhookRetention < 0 {
+		return errors.NewValidationError("webhook retention cannot be negative")
+	}
+	if c.QRCacheSize < 0 {
+		return errors.NewValidationError("QR cache size cannot be negative")
+	}
+	if c.ReportThreshold < 0 {
+		return errors.NewValidationError("report threshold cannot be negative")
+	}
+	if c.ReadyMinFreeDiskMB < 0 {
+		return errors.NewValidationError("ready min free disk cannot be negative")
+	}
+	if c.ShutdownDelay < 0 {
+		return errors.NewValidationError("shutdown delay cannot be negative")
+	}
+	switch c.LogFormat {
+	case "console", "json":
+	default:
+		return errors.NewValidationError("log format must be console or json")
+	}
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return errors.NewValidationError("log level must be debug, info, warn or error")
+	}
+	switch c.TracingExporter {
+	case "none", "otlp", "stdout":
+	default:
+		return errors.NewValidationError("tracing exporter must be none, otlp or stdout")
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		return errors.NewValidationError("tracing sample ratio must be between 0 and 1")
+	}
+	for _, scheme := range c.AllowedSchemes {
+		if !schemeRegex.MatchString(strings.TrimSpace(scheme)) {
+			return errors.NewValidationError(fmt.Sprintf("invalid allowed scheme %q", scheme))
+		}
+	}
 	return nil
 }
 
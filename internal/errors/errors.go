@@ -25,6 +25,7 @@ var (
 	ErrInternalError = errors.New("internal error")
 	ErrUnauthorized  = errors.New("unauthorized")
 	ErrForbidden     = errors.New("forbidden")
+	ErrRateLimited   = errors.New("rate limited")
 )
 
 // AppError is a custom error type that includes error type and context
@@ -58,6 +59,12 @@ func (e *AppError) Is(target error) bool {
 	if target == ErrInternalError && (e.Type == ErrorTypeInternal || e.Type == ErrorTypeDatabase) {
 		return true
 	}
+	if target == ErrForbidden && e.Type == ErrorTypeSecurity {
+		return true
+	}
+	if target == ErrRateLimited && e.Type == ErrorTypeRateLimit {
+		return true
+	}
 	return false
 }
 
@@ -98,6 +105,15 @@ func NewRateLimitError(message string) *AppError {
 	}
 }
 
+// NewSecurityError creates a new security error for rejected credentials or access
+func NewSecurityError(message string) *AppError {
+	return &AppError{
+		Type:    ErrorTypeSecurity,
+		Message: message,
+		Err:     ErrForbidden,
+	}
+}
+
 // NewInternalError creates a new internal error
 func NewInternalError(message string) *AppError {
 	return &AppError{
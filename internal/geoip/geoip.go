@@ -0,0 +1,61 @@
+package geoip
+
+import (
+	"net"
+	"strings"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// Resolver looks up client countries in a locally supplied MaxMind database.
+// A nil or unconfigured Resolver is valid and never resolves a country.
+type Resolver struct {
+	db *geoip2.Reader
+}
+
+// Open opens the MMDB file at path. An empty path yields a disabled resolver
+func Open(path string) (*Resolver, error) {
+	if path == "" {
+		return &Resolver{}, nil
+	}
+
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resolver{db: db}, nil
+}
+
+// Enabled reports whether a GeoIP database is loaded
+func (r *Resolver) Enabled() bool {
+	return r != nil && r.db != nil
+}
+
+// Country returns the ISO 3166-1 alpha-2 country code for ip, or an empty
+// string if the database is not configured or the address is unknown
+func (r *Resolver) Country(ip string) string {
+	if !r.Enabled() {
+		return ""
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	record, err := r.db.Country(parsed)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToUpper(record.Country.IsoCode)
+}
+
+// Close releases the underlying database
+func (r *Resolver) Close() error {
+	if !r.Enabled() {
+		return nil
+	}
+	return r.db.Close()
+}
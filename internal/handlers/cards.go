@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"html/template"
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/service"
+)
+
+// crawlerUserAgentRegex matches the link preview bots of chat apps and social
+// networks, which are served the social card instead of a redirect. Only the
+// bots themselves are matched: the in-app browsers of apps such as Pinterest
+// and Snapchat also name the app and must be redirected.
+var crawlerUserAgentRegex = regexp.MustCompile(`(?i)facebookexternalhit|facebot|twitterbot|slackbot|slack-imgproxy|discordbot|telegrambot|whatsapp|linkedinbot|skypeuripreview|pinterestbot|redditbot|embedly|iframely|mastodon|cardyb|vkshare|snap url preview`)
+
+// cardPage is the HTML served to link preview bots. The destination is
+// linked, not redirected to, so bots describe the short link itself.
+var cardPage = template.Must(template.New("card").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{or .Card.Title .ShortURL}}</title>
+<meta property="og:type" content="website">
+<meta property="og:url" content="{{.ShortURL}}">
+{{- with .Card.Title}}
+<meta property="og:title" content="{{.}}">
+<meta name="twitter:title" content="{{.}}">
+{{- end}}
+{{- with .Card.Description}}
+<meta name="description" content="{{.}}">
+<meta property="og:description" content="{{.}}">
+<meta name="twitter:description" content="{{.}}">
+{{- end}}
+{{- with .Card.ImageURL}}
+<meta property="og:image" content="{{.}}">
+<meta name="twitter:image" content="{{.}}">
+<meta name="twitter:card" content="summary_large_image">
+{{- else}}
+<meta name="twitter:card" content="summary">
+{{- end}}
+</head>
+<body>
+<h1>{{or .Card.Title .ShortURL}}</h1>
+{{- with .Card.Description}}
+<p>{{.}}</p>
+{{- end}}
+{{- with .Destination}}
+<p><a href="{{.}}">{{.}}</a></p>
+{{- end}}
+</body>
+</html>
+`))
+
+// cardPageData is rendered by cardPage
+type cardPageData struct {
+	Card        *models.SocialCard
+	ShortURL    string
+	Destination string // empty for protected links
+}
+
+// isCrawler reports whether the request comes from a link preview bot
+func isCrawler(c *fiber.Ctx) bool {
+	return crawlerUserAgentRegex.MatchString(c.Get(fiber.HeaderUserAgent))
+}
+
+// sendCard serves the social card of a link to a link preview bot. Bot
+// requests are not counted as visits.
+func (h *URLHandler) sendCard(c *fiber.Ctx, url *models.URL, card *models.SocialCard) error {
+	data := cardPageData{
+		Card:     card,
+		ShortURL: h.links.Build(c, url.Domain, url.ShortCode),
+	}
+	if !url.Protected {
+		data.Destination = service.HealthTarget(url)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return cardPage.Execute(c.Response().BodyWriter(), data)
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/service"
+)
+
+// CollectionHandler handles HTTP requests related to link-in-bio collections
+type CollectionHandler struct {
+	service *service.CollectionService
+	links   *LinkBuilder
+}
+
+// NewCollectionHandler creates a new collection handler
+func NewCollectionHandler(service *service.CollectionService, links *LinkBuilder) *CollectionHandler {
+	return &CollectionHandler{service: service, links: links}
+}
+
+// CreateCollection handles the creation of a new collection
+func (h *CollectionHandler) CreateCollection(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	var request service.CreateCollectionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	collection, err := h.service.CreateCollection(ctx, request)
+	if err != nil {
+		return serviceError(c, err, "Failed to create collection")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(h.collectionResponse(c, collection))
+}
+
+// GetCollection returns a collection with its items
+func (h *CollectionHandler) GetCollection(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	slug := c.Params("slug")
+	collection, err := h.service.GetCollection(ctx, slug)
+	if err != nil {
+		return serviceError(c, err, "Failed to retrieve collection", map[string]interface{}{"slug": slug})
+	}
+
+	return c.JSON(h.collectionResponse(c, collection))
+}
+
+// DeleteCollection deletes a collection
+func (h *CollectionHandler) DeleteCollection(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	slug := c.Params("slug")
+	if err := h.service.DeleteCollection(ctx, slug); err != nil {
+		return serviceError(c, err, "Failed to delete collection", map[string]interface{}{"slug": slug})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// AddItem appends a short URL to a collection
+func (h *CollectionHandler) AddItem(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	var request service.CollectionItemRequest
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	slug := c.Params("slug")
+	item, err := h.service.AddItem(ctx, slug, request)
+	if err != nil {
+		return serviceError(c, err, "Failed to add collection item", map[string]interface{}{"slug": slug})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(h.collectionItemResponse(c, slug, item))
+}
+
+// RemoveItem removes an item from a collection
+func (h *CollectionHandler) RemoveItem(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	itemID, err := strconv.ParseInt(c.Params("item"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid item ID")
+	}
+
+	slug := c.Params("slug")
+	if err := h.service.RemoveItem(ctx, slug, itemID); err != nil {
+		return serviceError(c, err, "Failed to remove collection item", map[string]interface{}{"slug": slug})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ReorderItems sets the order of a collection's items
+func (h *CollectionHandler) ReorderItems(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	var request struct {
+		ItemIDs []int64 `json:"item_ids"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	slug := c.Params("slug")
+	collection, err := h.service.ReorderItems(ctx, slug, request.ItemIDs)
+	if err != nil {
+		return serviceError(c, err, "Failed to reorder collection items", map[string]interface{}{"slug": slug})
+	}
+
+	return c.JSON(h.collectionResponse(c, collection))
+}
+
+// HandlePage serves the public collection page
+func (h *CollectionHandler) HandlePage(c *fiber.Ctx) error {
+	return sendPage(c, "collection.html")
+}
+
+// HandleItemClick attributes a click to a collection item and sends the
+// visitor through the item's short link, so it is tracked like any other visit
+func (h *CollectionHandler) HandleItemClick(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	slug := c.Params("slug")
+	itemID, err := strconv.ParseInt(c.Params("item"), 10, 64)
+	if err != nil {
+		return c.Redirect("/@" + slug)
+	}
+
+	item, err := h.service.RecordItemClick(ctx, slug, itemID)
+	if err != nil {
+		return c.Redirect("/@" + slug)
+	}
+
+	return c.Redirect(h.links.Build(c, item.Domain, item.ShortCode), fiber.StatusFound)
+}
+
+// collectionResponse adds public links to a collection
+func (h *CollectionHandler) collectionResponse(c *fiber.Ctx, collection *models.Collection) models.CollectionResponse {
+	items := make([]models.CollectionItemResponse, len(collection.Items))
+	for i, item := range collection.Items {
+		items[i] = h.collectionItemResponse(c, collection.Slug, item)
+	}
+	return models.CollectionResponse{
+		Collection: collection,
+		PageURL:    h.links.Build(c, "", "@"+collection.Slug),
+		Items:      items,
+	}
+}
+
+// collectionItemResponse adds public links to a collection item
+func (h *CollectionHandler) collectionItemResponse(c *fiber.Ctx, slug string, item *models.CollectionItem) models.CollectionItemResponse {
+	return models.CollectionItemResponse{
+		CollectionItem: item,
+		Link:           h.links.Build(c, "", "@"+slug+"/"+strconv.FormatInt(item.ID, 10)),
+		ShortURL:       h.links.Build(c, item.Domain, item.ShortCode),
+	}
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/nijaru/nano-link/internal/openapi"
+)
+
+// OpenAPISpec serves the OpenAPI document of the API
+func OpenAPISpec(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(openapi.Spec)
+}
+
+// APIDocs serves the interactive API documentation, which renders the
+// OpenAPI document
+func APIDocs(c *fiber.Ctx) error {
+	return sendPage(c, "docs.html")
+}
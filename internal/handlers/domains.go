@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nijaru/nano-link/internal/service"
+)
+
+// DomainHandler handles HTTP requests related to custom domains
+type DomainHandler struct {
+	service *service.DomainService
+}
+
+// NewDomainHandler creates a new domain handler
+func NewDomainHandler(service *service.DomainService) *DomainHandler {
+	return &DomainHandler{service: service}
+}
+
+// CreateDomain registers a custom domain
+func (h *DomainHandler) CreateDomain(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	var request struct {
+		Host string `json:"host"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	domain, err := h.service.CreateDomain(ctx, request.Host)
+	if err != nil {
+		return serviceError(c, err, "Failed to create domain")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(domain)
+}
+
+// ListDomains returns all custom domains
+func (h *DomainHandler) ListDomains(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	domains, err := h.service.ListDomains(ctx)
+	if err != nil {
+		return serviceError(c, err, "Failed to retrieve domains")
+	}
+
+	return c.JSON(domains)
+}
+
+// DeleteDomain removes a custom domain
+func (h *DomainHandler) DeleteDomain(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	host := c.Params("host")
+	if err := h.service.DeleteDomain(ctx, host); err != nil {
+		return serviceError(c, err, "Failed to delete domain", map[string]interface{}{"host": host})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
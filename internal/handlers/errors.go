@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	appErrors "github.com/nijaru/nano-link/internal/errors"
+	customLogger "github.com/nijaru/nano-link/internal/logger"
+)
+
+// serviceError maps a service error to a Fiber error. Client errors keep the
+// service message; anything else is logged and reported with message.
+func serviceError(c *fiber.Ctx, err error, message string, fields ...map[string]interface{}) error {
+	var appErr *appErrors.AppError
+	if errors.As(err, &appErr) {
+		switch {
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			return fiber.NewError(fiber.StatusBadRequest, appErr.Message)
+		case errors.Is(err, appErrors.ErrNotFound):
+			return fiber.NewError(fiber.StatusNotFound, appErr.Message)
+		case errors.Is(err, appErrors.ErrForbidden):
+			return fiber.NewError(fiber.StatusForbidden, appErr.Message)
+		case errors.Is(err, appErrors.ErrRateLimited):
+			return fiber.NewError(fiber.StatusTooManyRequests, appErr.Message)
+		}
+	}
+
+	customLogger.ErrorContext(c.UserContext(), err, message, fields...)
+	return fiber.NewError(fiber.StatusInternalServerError, message)
+}
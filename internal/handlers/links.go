@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LinkBuilder builds absolute short URLs for the default and custom domains
+type LinkBuilder struct {
+	base *url.URL // nil when BASE_URL is unset
+}
+
+// NewLinkBuilder creates a link builder rooted at baseURL. An empty baseURL
+// derives links from each request's scheme and host instead.
+func NewLinkBuilder(baseURL string) (*LinkBuilder, error) {
+	if baseURL == "" {
+		return &LinkBuilder{}, nil
+	}
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	return &LinkBuilder{base: base}, nil
+}
+
+// Host returns the host of the default domain, or "" when derived per request
+func (b *LinkBuilder) Host() string {
+	if b.base == nil {
+		return ""
+	}
+	return b.base.Hostname()
+}
+
+// Build returns the absolute URL of path on domain, or on the default domain
+// when domain is empty. Scheme and host come from BASE_URL when set and
+// otherwise from the request, honoring forwarding headers of trusted proxies.
+func (b *LinkBuilder) Build(c *fiber.Ctx, domain, path string) string {
+	scheme := c.Protocol()
+	if b.base != nil {
+		scheme = b.base.Scheme
+	}
+
+	switch {
+	case domain != "":
+		return scheme + "://" + domain + "/" + path
+	case b.base != nil:
+		return b.base.String() + "/" + path
+	default:
+		return scheme + "://" + c.Hostname() + "/" + path
+	}
+}
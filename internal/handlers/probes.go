@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/nijaru/nano-link/internal/probes"
+)
+
+// ProbeHandler serves the liveness and readiness probes
+type ProbeHandler struct {
+	prober *probes.Prober
+}
+
+// NewProbeHandler creates a new probe handler
+func NewProbeHandler(prober *probes.Prober) *ProbeHandler {
+	return &ProbeHandler{prober: prober}
+}
+
+// Livez reports whether the background tasks are still making progress
+func (h *ProbeHandler) Livez(c *fiber.Ctx) error {
+	return sendReport(c, h.prober.Live(c.UserContext()))
+}
+
+// Readyz reports whether the service can handle requests
+func (h *ProbeHandler) Readyz(c *fiber.Ctx) error {
+	return sendReport(c, h.prober.Ready(c.UserContext()))
+}
+
+// sendReport sends a probe report, with 503 if any check failed
+func sendReport(c *fiber.Ctx, report *probes.Report) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	if !report.OK() {
+		c.Status(fiber.StatusServiceUnavailable)
+	}
+	return c.JSON(report)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nijaru/nano-link/internal/qr"
+	"github.com/nijaru/nano-link/internal/service"
+)
+
+// QRHandler serves QR codes of short links
+type QRHandler struct {
+	urls      *service.URLService
+	generator *qr.Generator
+	links     *LinkBuilder
+}
+
+// NewQRHandler creates a new QR code handler
+func NewQRHandler(urls *service.URLService, generator *qr.Generator, links *LinkBuilder) *QRHandler {
+	return &QRHandler{urls: urls, generator: generator, links: links}
+}
+
+// GetQRCode returns a PNG or SVG QR code of a short link
+func (h *QRHandler) GetQRCode(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	opts, err := parseQROptions(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	code := c.Params("code")
+	domain, err := requestDomain(ctx, c, h.urls)
+	if err != nil {
+		return serviceError(c, err, "Failed to generate QR code", map[string]interface{}{"code": code})
+	}
+	url, err := h.urls.GetURL(ctx, domain, code)
+	if err != nil {
+		return serviceError(c, err, "Failed to generate QR code", map[string]interface{}{"code": code})
+	}
+
+	image, err := h.generator.Generate(h.links.Build(c, url.Domain, url.ShortCode), opts)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if opts.Format == qr.FormatSVG {
+		c.Set(fiber.HeaderContentType, "image/svg+xml")
+	} else {
+		c.Set(fiber.HeaderContentType, "image/png")
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+	return c.Send(image)
+}
+
+// parseQROptions reads the QR code options from the query string
+func parseQROptions(c *fiber.Ctx) (qr.Options, error) {
+	opts := qr.DefaultOptions()
+	var err error
+
+	if format := c.Query("format"); format != "" {
+		opts.Format = strings.ToLower(format)
+	}
+	if size := c.Query("size"); size != "" {
+		if opts.Size, err = strconv.Atoi(size); err != nil {
+			return opts, errors.New("size must be a number")
+		}
+	}
+	if margin := c.Query("margin"); margin != "" {
+		if opts.Margin, err = strconv.Atoi(margin); err != nil {
+			return opts, errors.New("margin must be a number")
+		}
+	}
+	if level := c.Query("level"); level != "" {
+		opts.Level = strings.ToUpper(level)
+	}
+	if fg := c.Query("fg"); fg != "" {
+		if opts.Foreground, err = qr.ParseColor(fg); err != nil {
+			return opts, err
+		}
+	}
+	if bg := c.Query("bg"); bg != "" {
+		if opts.Background, err = qr.ParseColor(bg); err != nil {
+			return opts, err
+		}
+	}
+	opts.Logo = c.QueryBool("logo")
+
+	return opts, opts.Validate()
+}
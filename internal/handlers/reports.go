@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nijaru/nano-link/internal/middleware"
+	"github.com/nijaru/nano-link/internal/service"
+)
+
+// ReportHandler handles HTTP requests related to abuse reports
+type ReportHandler struct {
+	service *service.ReportService
+	urls    *service.URLService
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(service *service.ReportService, urls *service.URLService) *ReportHandler {
+	return &ReportHandler{service: service, urls: urls}
+}
+
+// Report stores a public report of a short URL
+func (h *ReportHandler) Report(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	var request service.CreateReportRequest
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	domain, err := requestDomain(ctx, c, h.urls)
+	if err != nil {
+		return serviceError(c, err, "Failed to submit report")
+	}
+
+	code := c.Params("code")
+	report, err := h.service.CreateReport(ctx, domain, code, request, service.Reporter{
+		IP:        middleware.ClientIP(c),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	})
+	if err != nil {
+		return serviceError(c, err, "Failed to submit report", map[string]interface{}{"code": code})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"id":     report.ID,
+		"status": report.Status,
+	})
+}
+
+// ListReports returns the newest reports, optionally filtered by status
+func (h *ReportHandler) ListReports(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	reports, err := h.service.ListReports(ctx, c.Query("status"), c.QueryInt("limit", 50))
+	if err != nil {
+		return serviceError(c, err, "Failed to retrieve reports")
+	}
+
+	return c.JSON(reports)
+}
+
+// DismissReport closes a report as unfounded
+func (h *ReportHandler) DismissReport(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	id, err := reportID(c)
+	if err != nil {
+		return err
+	}
+
+	report, err := h.service.DismissReport(ctx, id)
+	if err != nil {
+		return serviceError(c, err, "Failed to dismiss report", map[string]interface{}{"id": id})
+	}
+
+	return c.JSON(report)
+}
+
+// ConfirmReport confirms a report and disables the reported link
+func (h *ReportHandler) ConfirmReport(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	id, err := reportID(c)
+	if err != nil {
+		return err
+	}
+
+	report, err := h.service.ConfirmReport(ctx, id)
+	if err != nil {
+		return serviceError(c, err, "Failed to confirm report", map[string]interface{}{"id": id})
+	}
+
+	return c.JSON(report)
+}
+
+// reportID parses the report ID in the request path
+func reportID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "Invalid report ID")
+	}
+	return id, nil
+}
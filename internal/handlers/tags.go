@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nijaru/nano-link/internal/service"
+)
+
+// TagHandler handles HTTP requests related to tags and folders
+type TagHandler struct {
+	service *service.TagService
+	urls    *service.URLService
+	links   *LinkBuilder
+}
+
+// NewTagHandler creates a new tag handler
+func NewTagHandler(service *service.TagService, urls *service.URLService, links *LinkBuilder) *TagHandler {
+	return &TagHandler{service: service, urls: urls, links: links}
+}
+
+// AddTags adds tags to a short URL
+func (h *TagHandler) AddTags(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	var request struct {
+		Tags []string `json:"tags"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	code := c.Params("code")
+	domain, err := requestDomain(ctx, c, h.urls)
+	if err != nil {
+		return serviceError(c, err, "Failed to add tags", map[string]interface{}{"code": code})
+	}
+
+	url, err := h.service.AddTags(ctx, domain, code, request.Tags)
+	if err != nil {
+		return serviceError(c, err, "Failed to add tags", map[string]interface{}{"code": code})
+	}
+
+	return c.JSON(publicURLResponse(c, h.links, url))
+}
+
+// RemoveTag removes a tag from a short URL
+func (h *TagHandler) RemoveTag(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	code := c.Params("code")
+	domain, err := requestDomain(ctx, c, h.urls)
+	if err != nil {
+		return serviceError(c, err, "Failed to remove tag", map[string]interface{}{"code": code})
+	}
+
+	if err := h.service.RemoveTag(ctx, domain, code, c.Params("tag")); err != nil {
+		return serviceError(c, err, "Failed to remove tag", map[string]interface{}{"code": code})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// SetFolder moves a short URL into a folder
+func (h *TagHandler) SetFolder(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	var request struct {
+		Folder string `json:"folder"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	return h.setFolder(ctx, c, request.Folder)
+}
+
+// ClearFolder moves a short URL out of its folder
+func (h *TagHandler) ClearFolder(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	return h.setFolder(ctx, c, "")
+}
+
+func (h *TagHandler) setFolder(ctx context.Context, c *fiber.Ctx, folder string) error {
+	code := c.Params("code")
+	domain, err := requestDomain(ctx, c, h.urls)
+	if err != nil {
+		return serviceError(c, err, "Failed to update folder", map[string]interface{}{"code": code})
+	}
+
+	url, err := h.service.SetFolder(ctx, domain, code, folder)
+	if err != nil {
+		return serviceError(c, err, "Failed to update folder", map[string]interface{}{"code": code})
+	}
+
+	return c.JSON(publicURLResponse(c, h.links, url))
+}
+
+// ListTags returns every tag in use with the number of links and visits
+func (h *TagHandler) ListTags(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	tags, err := h.service.ListTags(ctx)
+	if err != nil {
+		return serviceError(c, err, "Failed to list tags")
+	}
+
+	return c.JSON(fiber.Map{"tags": tags})
+}
+
+// GetTagStats returns usage statistics of the links with a tag
+func (h *TagHandler) GetTagStats(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	tag := c.Params("tag")
+	stats, err := h.service.GetTagStats(ctx, tag)
+	if err != nil {
+		return serviceError(c, err, "Failed to retrieve tag stats", map[string]interface{}{"tag": tag})
+	}
+
+	return c.JSON(stats)
+}
+
+// ListFolders returns every folder in use with the number of links and visits
+func (h *TagHandler) ListFolders(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	folders, err := h.service.ListFolders(ctx)
+	if err != nil {
+		return serviceError(c, err, "Failed to list folders")
+	}
+
+	return c.JSON(fiber.Map{"folders": folders})
+}
@@ -3,123 +3,385 @@ package handlers
 import (
 	"context"
 	"errors"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	appErrors "github.com/nijaru/nano-link/internal/errors"
 	customLogger "github.com/nijaru/nano-link/internal/logger"
+	"github.com/nijaru/nano-link/internal/metrics"
+	"github.com/nijaru/nano-link/internal/middleware"
 	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/repository"
 	"github.com/nijaru/nano-link/internal/service"
+	"github.com/nijaru/nano-link/internal/tracing"
 )
 
+// mobileUserAgentRegex matches user agents of devices that may handle app deep links
+var mobileUserAgentRegex = regexp.MustCompile(`(?i)android|iphone|ipad|ipod|mobile`)
+
 // URLHandler handles HTTP requests related to URLs
 type URLHandler struct {
 	service *service.URLService
+	links   *LinkBuilder
 }
 
 // NewURLHandler creates a new URL handler
-func NewURLHandler(service *service.URLService) *URLHandler {
-	return &URLHandler{service: service}
+func NewURLHandler(service *service.URLService, links *LinkBuilder) *URLHandler {
+	return &URLHandler{service: service, links: links}
+}
+
+// requestDomain returns the domain whose codes a request refers to: the
+// "domain" query parameter if given, otherwise the domain serving the Host
+func requestDomain(ctx context.Context, c *fiber.Ctx, urls *service.URLService) (string, error) {
+	if domain := c.Query("domain"); domain != "" {
+		return service.NormalizeHost(domain), nil
+	}
+	return urls.ResolveDomain(ctx, c.Hostname())
+}
+
+// lookupURL retrieves the URL for the code in the request path
+func (h *URLHandler) lookupURL(ctx context.Context, c *fiber.Ctx, code string) (*models.URL, error) {
+	domain, err := requestDomain(ctx, c, h.service)
+	if err != nil {
+		return nil, err
+	}
+	return h.service.GetURL(ctx, domain, code)
 }
 
 // CreateShortURL handles the creation of a new short URL
 func (h *URLHandler) CreateShortURL(c *fiber.Ctx) error {
 	// Extract request context
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
+	ctx, span := tracing.Start(ctx, "URLHandler.CreateShortURL")
+	defer span.End()
 
 	// Parse request body
 	var request service.CreateURLRequest
 	if err := c.BodyParser(&request); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
+	request.RequestHost = c.Hostname()
 
 	// Create short URL
-	url, err := h.service.CreateShortURL(ctx, request.URL, request.CustomCode)
+	url, err := h.service.CreateShortURL(ctx, request)
 	if err != nil {
 		var appErr *appErrors.AppError
 		if errors.As(err, &appErr) {
 			switch {
-			case errors.Is(err, appErrors.ErrInvalidInput):
+			case errors.Is(err, appErrors.ErrInvalidInput), errors.Is(err, appErrors.ErrForbidden):
+				metrics.ValidationFailures.WithLabelValues(rejectionReason(err)).Inc()
 				return fiber.NewError(fiber.StatusBadRequest, appErr.Message)
 			case errors.Is(err, appErrors.ErrInternalError):
-				customLogger.Error(err, "Failed to create short URL")
+				customLogger.ErrorContext(c.UserContext(), err, "Failed to create short URL")
 				return fiber.NewError(fiber.StatusInternalServerError, "Failed to create short URL")
 			default:
 				return fiber.NewError(fiber.StatusBadRequest, appErr.Message)
 			}
 		}
-		customLogger.Error(err, "Unexpected error creating short URL")
+		customLogger.ErrorContext(c.UserContext(), err, "Unexpected error creating short URL")
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create short URL")
 	}
 
 	return c.JSON(models.URLResponse{
 		URL:      *url,
-		ShortURL: buildShortURL(c, url.ShortCode),
+		ShortURL: h.links.Build(c, url.Domain, url.ShortCode),
 	})
 }
 
+// rejectionReason returns the metrics reason of a rejected link creation
+func rejectionReason(err error) string {
+	switch {
+	case errors.Is(err, service.ErrCodeConflict):
+		return "code_conflict"
+	case errors.Is(err, appErrors.ErrForbidden):
+		return "blocked"
+	default:
+		return "invalid"
+	}
+}
+
 // HandleRedirect handles redirecting short URLs to their original URL
 func (h *URLHandler) HandleRedirect(c *fiber.Ctx) error {
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
+	ctx, span := tracing.Start(ctx, "URLHandler.HandleRedirect")
+	defer span.End()
 	
 	code := c.Params("code")
 	if code == "" {
 		return c.Redirect("/") // Redirect to homepage if no code provided
 	}
 
-	url, err := h.service.GetURL(ctx, code)
+	// A trailing "+" shows the preview page instead of redirecting
+	if strings.HasSuffix(code, "+") {
+		return sendPage(c, "preview.html")
+	}
+
+	url, err := h.lookupURL(ctx, c, code)
 	if err != nil {
 		var appErr *appErrors.AppError
 		if errors.As(err, &appErr) && errors.Is(err, appErrors.ErrNotFound) {
+			metrics.Redirects.WithLabelValues("miss").Inc()
 			return c.Redirect("/") // Redirect to homepage if URL not found
 		}
-		customLogger.Error(err, "Failed to retrieve URL", map[string]interface{}{"code": code})
+		customLogger.ErrorContext(c.UserContext(), err, "Failed to retrieve URL", map[string]interface{}{"code": code})
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to process redirect")
 	}
 
-	// Increment visits with a separate context that won't be canceled when this handler returns
-	backgroundCtx := context.Background()
+	if url.Disabled {
+		metrics.Redirects.WithLabelValues("disabled").Inc()
+		return sendDisabled(c)
+	}
+	metrics.Redirects.WithLabelValues("hit").Inc()
+
+	// Link preview bots get the social card instead of following the redirect
+	if isCrawler(c) {
+		if card := service.SocialCardFor(url); card != nil {
+			return h.sendCard(c, url, card)
+		}
+	}
+
+	if url.Protected {
+		return sendPage(c, "password.html")
+	}
+
+	// The interstitial page counts as the visit and forwards the visitor itself
+	if h.service.ShowInterstitial(url) {
+		h.recordVisit(c, url)
+		return sendPage(c, "preview.html")
+	}
+
+	return h.redirectVisitor(c, url)
+}
+
+// HandlePreview serves the preview page for a short URL
+func (h *URLHandler) HandlePreview(c *fiber.Ctx) error {
+	return sendPage(c, "preview.html")
+}
+
+// GetPreview returns the details shown on the preview and interstitial pages
+func (h *URLHandler) GetPreview(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "URLHandler.GetPreview")
+	defer span.End()
+
+	code := c.Params("code")
+	if code == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Code parameter is required")
+	}
+
+	domain, err := requestDomain(ctx, c, h.service)
+	if err != nil {
+		customLogger.ErrorContext(c.UserContext(), err, "Failed to resolve domain", map[string]interface{}{"host": c.Hostname()})
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve preview")
+	}
+
+	preview, err := h.service.GetPreview(ctx, domain, code, h.service.LookupCountry(middleware.ClientIP(c)))
+	if err != nil {
+		if errors.Is(err, appErrors.ErrNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, "URL not found")
+		}
+		customLogger.ErrorContext(c.UserContext(), err, "Failed to retrieve preview", map[string]interface{}{"code": code})
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve preview")
+	}
+	preview.ShortURL = h.links.Build(c, preview.Domain, preview.ShortCode)
+
+	return c.JSON(preview)
+}
+
+// UnlockRedirect verifies the password submitted from the password form and
+// redirects to the destination on success
+func (h *URLHandler) UnlockRedirect(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "URLHandler.UnlockRedirect")
+	defer span.End()
+
+	code := c.Params("code")
+	if code == "" {
+		return c.Redirect("/")
+	}
+
+	domain, err := h.service.ResolveDomain(ctx, c.Hostname())
+	if err != nil {
+		customLogger.ErrorContext(c.UserContext(), err, "Failed to resolve domain", map[string]interface{}{"host": c.Hostname()})
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to process redirect")
+	}
+
+	url, err := h.service.Unlock(ctx, domain, code, c.FormValue("password"))
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrNotFound):
+			return c.Redirect("/")
+		case errors.Is(err, appErrors.ErrForbidden):
+			return c.Redirect("/"+code+"?error=invalid", fiber.StatusSeeOther)
+		case errors.Is(err, appErrors.ErrRateLimited):
+			return c.Redirect("/"+code+"?error=throttled", fiber.StatusSeeOther)
+		}
+		customLogger.ErrorContext(c.UserContext(), err, "Failed to unlock URL", map[string]interface{}{"code": code})
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to process redirect")
+	}
+
+	if url.Disabled {
+		return sendDisabled(c)
+	}
+
+	return h.redirectVisitor(c, url, fiber.StatusSeeOther)
+}
+
+// redirectVisitor records a visit and redirects to the visitor's destination
+func (h *URLHandler) redirectVisitor(c *fiber.Ctx, url *models.URL, status ...int) error {
+	country := h.recordVisit(c, url)
+
+	redirectStatus := fiber.StatusTemporaryRedirect
+	if len(status) > 0 {
+		redirectStatus = status[0]
+	}
+
+	destination := h.service.DestinationFor(url, country)
+	if url.FallbackURL != "" && !service.IsWebURL(destination) {
+		// Only mobile devices are expected to have the app installed. The
+		// deep link page tries the app and falls back to the web page.
+		if !mobileUserAgentRegex.MatchString(c.Get(fiber.HeaderUserAgent)) {
+			return c.Redirect(url.FallbackURL, redirectStatus)
+		}
+		return sendPage(c, "deeplink.html")
+	}
+
+	return c.Redirect(destination, redirectStatus)
+}
+
+// recordVisit records a visit in the background and returns the visitor's country
+func (h *URLHandler) recordVisit(c *fiber.Ctx, url *models.URL) string {
+	ip := middleware.ClientIP(c)
+	country := h.service.LookupCountry(ip)
+	customLogger.DebugContext(c.UserContext(), "Visit", map[string]interface{}{
+		"code":    url.ShortCode,
+		"ip":      ip,
+		"country": country,
+	})
+
+	// Use a separate context that won't be canceled when the handler returns,
+	// but still belongs to the request's trace
+	backgroundCtx := context.WithoutCancel(c.UserContext())
 	go func(ctx context.Context, code string) {
-		if err := h.service.IncrementVisits(ctx, code); err != nil {
-			customLogger.Error(err, "Failed to increment visits", map[string]interface{}{"code": code})
+		if err := h.service.RecordVisit(ctx, url, country); err != nil {
+			customLogger.ErrorContext(ctx, err, "Failed to record visit", map[string]interface{}{"code": code})
 		}
-	}(backgroundCtx, code)
+	}(backgroundCtx, url.ShortCode)
+	return country
+}
 
-	return c.Redirect(url.OriginalURL, fiber.StatusTemporaryRedirect)
+// sendPage serves an HTML page from the static directory. These pages load
+// link details from the API, so they must never be cached per short code.
+func sendPage(c *fiber.Ctx, name string) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.SendFile("./static/" + name)
+}
+
+// sendDisabled serves the page shown in place of a disabled link
+func sendDisabled(c *fiber.Ctx) error {
+	c.Status(fiber.StatusGone)
+	return sendPage(c, "disabled.html")
 }
 
 // GetURLInfo returns information about a shortened URL
 func (h *URLHandler) GetURLInfo(c *fiber.Ctx) error {
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
+	ctx, span := tracing.Start(ctx, "URLHandler.GetURLInfo")
+	defer span.End()
 	
 	code := c.Params("code")
 	if code == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "Code parameter is required")
 	}
 
-	url, err := h.service.GetURL(ctx, code)
+	url, err := h.lookupURL(ctx, c, code)
 	if err != nil {
 		var appErr *appErrors.AppError
 		if errors.As(err, &appErr) && errors.Is(err, appErrors.ErrNotFound) {
 			return fiber.NewError(fiber.StatusNotFound, "URL not found")
 		}
-		customLogger.Error(err, "Failed to retrieve URL", map[string]interface{}{"code": code})
+		customLogger.ErrorContext(c.UserContext(), err, "Failed to retrieve URL", map[string]interface{}{"code": code})
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve URL")
 	}
 
+	return c.JSON(publicURLResponse(c, h.links, url))
+}
+
+// UpdateURL changes the title, description, notes or social card of a short URL
+func (h *URLHandler) UpdateURL(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "URLHandler.UpdateURL")
+	defer span.End()
+
+	var request service.UpdateURLRequest
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	code := c.Params("code")
+	domain, err := requestDomain(ctx, c, h.service)
+	if err != nil {
+		return serviceError(c, err, "Failed to update URL", map[string]interface{}{"code": code})
+	}
+
+	url, err := h.service.UpdateURL(ctx, domain, code, request)
+	if err != nil {
+		return serviceError(c, err, "Failed to update URL", map[string]interface{}{"code": code})
+	}
+
 	return c.JSON(models.URLResponse{
 		URL:      *url,
-		ShortURL: buildShortURL(c, url.ShortCode),
+		ShortURL: h.links.Build(c, url.Domain, url.ShortCode),
+	})
+}
+
+// GetCountryVisits returns the per-country visit breakdown for a short URL
+func (h *URLHandler) GetCountryVisits(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "URLHandler.GetCountryVisits")
+	defer span.End()
+
+	code := c.Params("code")
+	if code == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Code parameter is required")
+	}
+
+	domain, err := requestDomain(ctx, c, h.service)
+	if err != nil {
+		customLogger.ErrorContext(c.UserContext(), err, "Failed to resolve domain", map[string]interface{}{"host": c.Hostname()})
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve country visits")
+	}
+
+	visits, err := h.service.GetCountryVisits(ctx, domain, code)
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) && errors.Is(err, appErrors.ErrNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, "URL not found")
+		}
+		customLogger.ErrorContext(c.UserContext(), err, "Failed to retrieve country visits", map[string]interface{}{"code": code})
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve country visits")
+	}
+
+	return c.JSON(fiber.Map{
+		"countries": visits,
 	})
 }
 
 // GetRecentURLs returns recently created short URLs
 func (h *URLHandler) GetRecentURLs(c *fiber.Ctx) error {
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
+	ctx, span := tracing.Start(ctx, "URLHandler.GetRecentURLs")
+	defer span.End()
 	
 	// Parse query parameters
 	limit := 10
@@ -130,43 +392,99 @@ func (h *URLHandler) GetRecentURLs(c *fiber.Ctx) error {
 		}
 	}
 
-	urls, err := h.service.GetRecentURLs(ctx, limit)
+	filter := repository.URLFilter{
+		Limit:       limit,
+		Health:      c.Query("health"),
+		Sort:        c.Query("sort"),
+		Destination: c.Query("destination"),
+		Search:      c.Query("q"),
+		Owner:       c.Query("owner"),
+		Status:      c.Query("status"),
+		Tag:         c.Query("tag"),
+		Folder:      c.Query("folder"),
+	}
+	switch c.Query("order") {
+	case "", "desc":
+	case "asc":
+		filter.Ascending = true
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "order must be asc or desc")
+	}
+
+	var err error
+	if filter.CreatedAfter, err = parseDateQuery(c, "created_after"); err != nil {
+		return err
+	}
+	if filter.CreatedBefore, err = parseDateQuery(c, "created_before"); err != nil {
+		return err
+	}
+
+	page, err := h.service.GetRecentURLs(ctx, filter, c.Query("cursor"))
 	if err != nil {
-		customLogger.Error(err, "Failed to retrieve recent URLs")
-		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve recent URLs")
+		return serviceError(c, err, "Failed to retrieve recent URLs")
 	}
 
 	// Convert URLs to responses with full short URLs
-	responses := make([]models.URLResponse, len(urls))
-	for i, url := range urls {
-		responses[i] = models.URLResponse{
-			URL:      *url,
-			ShortURL: buildShortURL(c, url.ShortCode),
-		}
+	responses := make([]models.URLResponse, len(page.URLs))
+	for i, url := range page.URLs {
+		responses[i] = publicURLResponse(c, h.links, url)
 	}
 
-	return c.JSON(fiber.Map{
+	result := fiber.Map{
 		"urls": responses,
-	})
+	}
+	if page.NextCursor != "" {
+		result["next_cursor"] = page.NextCursor
+	}
+	return c.JSON(result)
+}
+
+// parseDateQuery parses an optional RFC 3339 time or YYYY-MM-DD date (UTC)
+// from a query parameter
+func parseDateQuery(c *fiber.Ctx, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fiber.NewError(fiber.StatusBadRequest, name+" must be an RFC 3339 time or a YYYY-MM-DD date")
 }
 
 // GetStats returns usage statistics
 func (h *URLHandler) GetStats(c *fiber.Ctx) error {
-	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
+	ctx, span := tracing.Start(ctx, "URLHandler.GetStats")
+	defer span.End()
 	
 	stats, err := h.service.GetStats(ctx)
 	if err != nil {
-		customLogger.Error(err, "Failed to retrieve stats")
+		customLogger.ErrorContext(c.UserContext(), err, "Failed to retrieve stats")
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve stats")
 	}
 
 	return c.JSON(stats)
 }
 
-// buildShortURL builds the full short URL from a code
-func buildShortURL(c *fiber.Ctx, code string) string {
-	return c.Protocol() + "://" + c.Hostname() + "/" + code
+// publicURLResponse builds a URL response for unauthenticated readers, hiding
+// private notes and the destinations of password-protected links
+func publicURLResponse(c *fiber.Ctx, links *LinkBuilder, url *models.URL) models.URLResponse {
+	public := *url
+	public.Notes = ""
+	if public.Protected {
+		public.OriginalURL = ""
+		public.GeoTargets = nil
+		public.FallbackURL = ""
+	}
+	return models.URLResponse{
+		URL:      public,
+		ShortURL: links.Build(c, url.Domain, url.ShortCode),
+	}
 }
 
 // ErrorHandler handles application errors
@@ -183,20 +501,20 @@ func ErrorHandler(c *fiber.Ctx, err error) error {
 
 	// Log non-400 errors
 	if code >= 500 {
-		customLogger.Error(err, "Server error", map[string]interface{}{
+		customLogger.ErrorContext(c.UserContext(), err, "Server error", map[string]interface{}{
 			"status":  code,
 			"path":    c.Path(),
 			"method":  c.Method(),
-			"ip":      c.IP(),
-			"message": message,
+			"ip":      middleware.ClientIP(c),
+			"detail":  message,
 		})
 	} else {
-		customLogger.Debug("Client error", map[string]interface{}{
+		customLogger.DebugContext(c.UserContext(), "Client error", map[string]interface{}{
 			"status":  code,
 			"path":    c.Path(),
 			"method":  c.Method(),
-			"ip":      c.IP(),
-			"message": message,
+			"ip":      middleware.ClientIP(c),
+			"detail":  message,
 		})
 	}
 
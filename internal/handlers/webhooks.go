@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nijaru/nano-link/internal/repository"
+	"github.com/nijaru/nano-link/internal/service"
+)
+
+// WebhookHandler handles HTTP requests related to webhooks
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// CreateWebhook subscribes a URL to link events. The response holds the
+// signing secret, which is not shown again.
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	var request service.CreateWebhookRequest
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	webhook, err := h.service.CreateWebhook(ctx, request)
+	if err != nil {
+		return serviceError(c, err, "Failed to create webhook")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(webhook)
+}
+
+// ListWebhooks returns every webhook, without secrets
+func (h *WebhookHandler) ListWebhooks(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	webhooks, err := h.service.ListWebhooks(ctx)
+	if err != nil {
+		return serviceError(c, err, "Failed to retrieve webhooks")
+	}
+
+	return c.JSON(webhooks)
+}
+
+// DeleteWebhook deletes a webhook and its deliveries
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	id, err := paramID(c, "Invalid webhook ID")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.DeleteWebhook(ctx, id); err != nil {
+		return serviceError(c, err, "Failed to delete webhook", map[string]interface{}{"id": id})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListWebhookDeliveries returns the newest deliveries of a webhook,
+// optionally filtered by status
+func (h *WebhookHandler) ListWebhookDeliveries(c *fiber.Ctx) error {
+	id, err := paramID(c, "Invalid webhook ID")
+	if err != nil {
+		return err
+	}
+	return h.listDeliveries(c, id)
+}
+
+// ListDeliveries returns the newest deliveries of every webhook, optionally
+// filtered by status; status=dead lists the dead letters
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	return h.listDeliveries(c, 0)
+}
+
+// RetryDelivery queues a delivered or dead delivery to be sent again
+func (h *WebhookHandler) RetryDelivery(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	id, err := paramID(c, "Invalid delivery ID")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.RetryDelivery(ctx, id); err != nil {
+		return serviceError(c, err, "Failed to retry delivery", map[string]interface{}{"id": id})
+	}
+
+	// SendStatus would add "Accepted" as a text body the API does not document
+	return c.Status(fiber.StatusAccepted).Send(nil)
+}
+
+// listDeliveries returns the deliveries of a webhook, or of all webhooks if
+// webhookID is 0
+func (h *WebhookHandler) listDeliveries(c *fiber.Ctx, webhookID int64) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	deliveries, err := h.service.ListDeliveries(ctx, repository.DeliveryFilter{
+		WebhookID: webhookID,
+		Status:    c.Query("status"),
+		Limit:     c.QueryInt("limit", 50),
+	})
+	if err != nil {
+		return serviceError(c, err, "Failed to retrieve deliveries")
+	}
+
+	return c.JSON(deliveries)
+}
+
+// paramID parses the positive numeric ID in the route
+func paramID(c *fiber.Ctx, message string) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, message)
+	}
+	return id, nil
+}
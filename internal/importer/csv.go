@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// csvSource reads a CSV file whose first row names the columns
+type csvSource struct {
+	reader *csv.Reader
+	header []string
+	mapper *mapper
+	index  int
+}
+
+func newCSVSource(r io.Reader, m *mapper) (*csvSource, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("CSV file is empty")
+		}
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i, name := range header {
+		header[i] = normalizeColumn(name)
+	}
+	return &csvSource{reader: reader, header: header, mapper: m}, nil
+}
+
+func (s *csvSource) Next() (*Entry, error) {
+	record, err := s.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	s.index++
+	if err != nil {
+		// Malformed quoting cannot be skipped reliably
+		return nil, fmt.Errorf("entry %d: %w", s.index, err)
+	}
+
+	row := make(map[string]string, len(record))
+	for i, value := range record {
+		if i < len(s.header) {
+			row[s.header[i]] = value
+		}
+	}
+
+	exported, err := s.mapper.toURL(row)
+	if err != nil {
+		return nil, &EntryError{Index: s.index, Err: err}
+	}
+	return &Entry{Index: s.index, URL: exported}, nil
+}
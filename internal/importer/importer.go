@@ -0,0 +1,279 @@
+// Package importer reads links exported by nano-link and other shorteners.
+// Every format is turned into service.ExportedURL values, so imported links
+// go through the same validation as links created through the API.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nijaru/nano-link/internal/service"
+)
+
+// Supported formats
+const (
+	FormatNanoLink  = "nano-link"  // JSON lines written by the export command
+	FormatYOURLSSQL = "yourls-sql" // mysqldump of the YOURLS url table
+	FormatYOURLSCSV = "yourls-csv" // CSV with YOURLS column names
+	FormatBitlyCSV  = "bitly-csv"  // CSV export of Bitly links
+	FormatCSV       = "csv"        // CSV with a header row
+	FormatJSON      = "json"       // JSON array or stream of objects
+)
+
+// Formats lists the supported formats
+var Formats = []string{FormatNanoLink, FormatYOURLSSQL, FormatYOURLSCSV, FormatBitlyCSV, FormatCSV, FormatJSON}
+
+// Fields that columns can be mapped to
+const (
+	FieldCode    = "code"
+	FieldURL     = "url"
+	FieldCreated = "created"
+	FieldVisits  = "visits"
+	FieldTitle   = "title"
+)
+
+// defaultColumns lists, per format, the columns tried for each field in order
+var defaultColumns = map[string]map[string][]string{
+	FormatYOURLSSQL: yourlsColumns,
+	FormatYOURLSCSV: yourlsColumns,
+	FormatBitlyCSV: {
+		FieldCode:    {"bitlink", "link", "short_url", "custom_bitlinks", "id"},
+		FieldURL:     {"long_url", "url", "destination"},
+		FieldCreated: {"created_utc", "created", "created_at", "date_created"},
+		FieldVisits:  {"clicks", "total_clicks", "user_clicks"},
+		FieldTitle:   {"title"},
+	},
+	FormatCSV:  genericColumns,
+	FormatJSON: genericColumns,
+}
+
+var yourlsColumns = map[string][]string{
+	FieldCode:    {"keyword"},
+	FieldURL:     {"url"},
+	FieldCreated: {"timestamp"},
+	FieldVisits:  {"clicks"},
+	FieldTitle:   {"title"},
+}
+
+var genericColumns = map[string][]string{
+	FieldCode:    {"short_code", "code", "keyword", "slug", "alias", "short_url", "link"},
+	FieldURL:     {"original_url", "url", "long_url", "destination", "target"},
+	FieldCreated: {"created_at", "created", "timestamp", "date", "created_utc"},
+	FieldVisits:  {"visits", "clicks", "hits", "total_clicks"},
+	FieldTitle:   {"title", "name"},
+}
+
+// Options controls how an export is read
+type Options struct {
+	Format string
+
+	// Mapping maps fields (code, url, created, visits, title) to column names,
+	// overriding the defaults of the format
+	Mapping map[string]string
+
+	// Domain is given to links that do not name one
+	Domain string
+
+	// NoExpire exempts the links from age-based expiry. Links from YOURLS and
+	// Bitly are always exempt, since their creation dates predate the import.
+	NoExpire bool
+}
+
+// foreignFormats are the exports of other shorteners, whose links would
+// otherwise expire as soon as they are imported
+var foreignFormats = map[string]bool{
+	FormatYOURLSSQL: true,
+	FormatYOURLSCSV: true,
+	FormatBitlyCSV:  true,
+}
+
+// Entry is one link read from an export
+type Entry struct {
+	Index int // 1-based position in the input
+	URL   service.ExportedURL
+}
+
+// EntryError reports an entry that could not be read. Reading can continue
+// with the next entry.
+type EntryError struct {
+	Index int
+	Err   error
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("entry %d: %v", e.Index, e.Err)
+}
+
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// Source yields the links of an export
+type Source interface {
+	// Next returns the next entry, an *EntryError for an entry that could not
+	// be read, or io.EOF at the end of the input
+	Next() (*Entry, error)
+}
+
+// NewSource returns a Source reading r in the given format
+func NewSource(r io.Reader, opts Options) (Source, error) {
+	if opts.Format == FormatNanoLink || opts.Format == "" {
+		return newNanoLinkSource(r, opts), nil
+	}
+
+	defaults, ok := defaultColumns[opts.Format]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q (supported: %s)", opts.Format, strings.Join(Formats, ", "))
+	}
+	columns := make(map[string][]string, len(defaults))
+	for field, names := range defaults {
+		columns[field] = names
+	}
+	for field, column := range opts.Mapping {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("unknown field %q in mapping (supported: code, url, created, visits, title)", field)
+		}
+		columns[field] = []string{normalizeColumn(column)}
+	}
+
+	m := &mapper{columns: columns, domain: opts.Domain, noExpire: opts.NoExpire || foreignFormats[opts.Format]}
+	switch opts.Format {
+	case FormatYOURLSSQL:
+		return newSQLSource(r, m)
+	case FormatJSON:
+		return newJSONSource(r, m)
+	default:
+		return newCSVSource(r, m)
+	}
+}
+
+// ParseMapping parses a mapping such as "code=slug,url=long_url"
+func ParseMapping(s string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		field, column, ok := strings.Cut(pair, "=")
+		field, column = strings.TrimSpace(field), strings.TrimSpace(column)
+		if !ok || field == "" || column == "" {
+			return nil, fmt.Errorf("invalid mapping %q, expected field=column", pair)
+		}
+		mapping[strings.ToLower(field)] = column
+	}
+	return mapping, nil
+}
+
+// mapper turns rows of named columns into exported URLs
+type mapper struct {
+	columns  map[string][]string // field -> candidate normalized column names
+	domain   string
+	noExpire bool
+}
+
+// toURL builds an exported URL from a row keyed by normalized column names
+func (m *mapper) toURL(row map[string]string) (service.ExportedURL, error) {
+	var exported service.ExportedURL
+	exported.Domain = m.domain
+	exported.NoExpire = m.noExpire
+
+	exported.ShortCode = codeFromValue(m.value(row, FieldCode))
+	if exported.ShortCode == "" {
+		return exported, errors.New("missing short code")
+	}
+	exported.OriginalURL = m.value(row, FieldURL)
+	if exported.OriginalURL == "" {
+		return exported, errors.New("missing destination URL")
+	}
+
+	if created := m.value(row, FieldCreated); created != "" {
+		t, err := parseTime(created)
+		if err != nil {
+			return exported, err
+		}
+		exported.CreatedAt = t
+	}
+
+	if visits := m.value(row, FieldVisits); visits != "" {
+		n, err := strconv.Atoi(strings.ReplaceAll(visits, ",", ""))
+		if err != nil {
+			return exported, fmt.Errorf("invalid visit count %q", visits)
+		}
+		exported.Visits = n
+	}
+	exported.Title = m.value(row, FieldTitle)
+
+	return exported, nil
+}
+
+// value returns the first non-empty candidate column of a field
+func (m *mapper) value(row map[string]string, field string) string {
+	for _, column := range m.columns[field] {
+		if v := strings.TrimSpace(row[column]); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// codeFromValue extracts the short code from a code column, which may hold a
+// full short link such as "bit.ly/3xYz" or "https://sho.rt/abc"
+func codeFromValue(value string) string {
+	if !strings.Contains(value, "/") {
+		return value
+	}
+	if !strings.Contains(value, "://") {
+		value = "https://" + value
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return ""
+	}
+	return strings.Trim(u.Path, "/")
+}
+
+// normalizeColumn lowercases a column name and replaces runs of other
+// characters with underscores, so "Created (UTC)" becomes "created_utc"
+func normalizeColumn(name string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+// timeLayouts are the date formats found in shortener exports. Times without
+// a zone are taken as UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05 -0700 MST",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseTime parses a date in one of timeLayouts or as Unix seconds
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
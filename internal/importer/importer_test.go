@@ -0,0 +1,206 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		// time.RFC3339Nano
+		{"2021-03-04T05:06:07Z", time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{"2021-03-04T05:06:07.123456789Z", time.Date(2021, 3, 4, 5, 6, 7, 123456789, time.UTC)},
+		{"2021-03-04T07:06:07+02:00", time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)},
+		// "2006-01-02 15:04:05 -0700 MST"
+		{"2021-03-04 00:06:07 -0500 EST", time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)},
+		// "2006-01-02 15:04:05 -0700"
+		{"2021-03-04 07:06:07 +0200", time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)},
+		// "2006-01-02 15:04:05"
+		{"2021-03-04 05:06:07", time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)},
+		// "2006-01-02T15:04:05"
+		{"2021-03-04T05:06:07", time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)},
+		// "2006-01-02"
+		{"2021-03-04", time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)},
+		// Unix seconds
+		{"1614834367", time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{"0", time.Unix(0, 0).UTC()},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTime(tt.input)
+		if err != nil {
+			t.Errorf("parseTime(%q) error = %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("parseTime(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "yesterday", "04/03/2021", "2021-13-01", "1614834367.5"} {
+		if _, err := parseTime(input); err == nil {
+			t.Errorf("parseTime(%q) error = nil, want error", input)
+		}
+	}
+}
+
+func TestTimeLayoutsCovered(t *testing.T) {
+	// Every layout must be reachable: a time formatted with it parses back
+	want := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	for _, layout := range timeLayouts {
+		input := want.Format(layout)
+		got, err := parseTime(input)
+		if err != nil {
+			t.Errorf("parseTime(%q) for layout %q error = %v", input, layout, err)
+			continue
+		}
+		if layout == "2006-01-02" {
+			want := time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)
+			if !got.Equal(want) {
+				t.Errorf("parseTime(%q) = %v, want %v", input, got, want)
+			}
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("parseTime(%q) for layout %q = %v, want %v", input, layout, got, want)
+		}
+	}
+}
+
+func TestCodeFromValue(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"abc", "abc"},
+		{"3abCDef", "3abCDef"},
+		{"bit.ly/3abCDef", "3abCDef"},
+		{"bit.ly/3abCDef/", "3abCDef"},
+		{"https://bit.ly/3abCDef", "3abCDef"},
+		{"http://sho.rt/abc?utm=1", "abc"},
+		{"https://sho.rt/abc#top", "abc"},
+		{"j.mp/xyz", "xyz"},
+		{"bit.ly/", ""},
+		{"bit.ly/%zz", ""},
+	}
+
+	for _, tt := range tests {
+		if got := codeFromValue(tt.input); got != tt.want {
+			t.Errorf("codeFromValue(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeColumn(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"keyword", "keyword"},
+		{"Long URL", "long_url"},
+		{"Created (UTC)", "created_utc"},
+		{"  Total-Clicks  ", "total_clicks"},
+		{"short_code", "short_code"},
+		{"__id__", "id"},
+		{"Date/Time", "date_time"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeColumn(tt.input); got != tt.want {
+			t.Errorf("normalizeColumn(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	mapping, err := ParseMapping("code=slug, URL=Long URL,,visits=hits")
+	if err != nil {
+		t.Fatalf("ParseMapping() error = %v", err)
+	}
+	want := map[string]string{"code": "slug", "url": "Long URL", "visits": "hits"}
+	if len(mapping) != len(want) {
+		t.Fatalf("ParseMapping() = %v, want %v", mapping, want)
+	}
+	for field, column := range want {
+		if mapping[field] != column {
+			t.Errorf("mapping[%q] = %q, want %q", field, mapping[field], column)
+		}
+	}
+
+	for _, input := range []string{"code", "code=", "=slug"} {
+		if _, err := ParseMapping(input); err == nil {
+			t.Errorf("ParseMapping(%q) error = nil, want error", input)
+		}
+	}
+	if _, err := NewSource(strings.NewReader("a\n"), Options{Format: FormatCSV, Mapping: map[string]string{"owner": "x"}}); err == nil {
+		t.Error("NewSource() with an unknown field error = nil, want error")
+	}
+}
+
+func TestBitlyCSVSource(t *testing.T) {
+	csv := "Bitlink,Long URL,Title,Created (UTC),Clicks\n" +
+		"bit.ly/3abCDef,https://example.com/launch,\"Launch, day\",2021-03-04 05:06:07,\"1,024\"\n" +
+		"https://bit.ly/xyz,https://example.com/x,,2021-03-04T05:06:07Z,0\n" +
+		",https://example.com/missing,,2021-03-04,1\n"
+
+	source, err := NewSource(strings.NewReader(csv), Options{Format: FormatBitlyCSV})
+	if err != nil {
+		t.Fatalf("NewSource() error = %v", err)
+	}
+	got, failed := readEntries(t, source)
+
+	created := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	want := []importedLink{
+		{"3abCDef", "https://example.com/launch", "Launch, day", created, 1024},
+		{"xyz", "https://example.com/x", "", created, 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("read %+v, want %+v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("link %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if len(failed) != 1 || failed[0] != 3 {
+		t.Errorf("failed entries = %v, want [3]", failed)
+	}
+}
+
+func TestNoExpire(t *testing.T) {
+	tests := []struct {
+		format   string
+		input    string
+		noExpire bool
+		want     bool
+	}{
+		{FormatYOURLSSQL, "INSERT INTO yourls_url VALUES ('a','https://example.com/','','2019-05-01','',0);", false, true},
+		{FormatYOURLSCSV, "keyword,url\na,https://example.com/\n", false, true},
+		{FormatBitlyCSV, "Bitlink,Long URL\nbit.ly/a,https://example.com/\n", false, true},
+		{FormatCSV, "code,url\na,https://example.com/\n", false, false},
+		{FormatCSV, "code,url\na,https://example.com/\n", true, true},
+		{FormatJSON, `[{"code":"a","url":"https://example.com/"}]`, false, false},
+		{FormatNanoLink, `{"short_code":"a","original_url":"https://example.com/"}`, false, false},
+		{FormatNanoLink, `{"short_code":"a","original_url":"https://example.com/","no_expire":true}`, false, true},
+		{FormatNanoLink, `{"short_code":"a","original_url":"https://example.com/"}`, true, true},
+	}
+
+	for _, tt := range tests {
+		source, err := NewSource(strings.NewReader(tt.input), Options{Format: tt.format, NoExpire: tt.noExpire})
+		if err != nil {
+			t.Fatalf("NewSource(%s) error = %v", tt.format, err)
+		}
+		entry, err := source.Next()
+		if err != nil {
+			t.Fatalf("%s: Next() error = %v", tt.format, err)
+		}
+		if entry.URL.NoExpire != tt.want {
+			t.Errorf("%s with NoExpire %v: link NoExpire = %v, want %v", tt.format, tt.noExpire, entry.URL.NoExpire, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nijaru/nano-link/internal/service"
+)
+
+// nanoLinkSource reads the JSON lines written by the export command
+type nanoLinkSource struct {
+	scanner  *bufio.Scanner
+	domain   string
+	noExpire bool
+	index    int
+}
+
+func newNanoLinkSource(r io.Reader, opts Options) *nanoLinkSource {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return &nanoLinkSource{scanner: scanner, domain: opts.Domain, noExpire: opts.NoExpire}
+}
+
+func (s *nanoLinkSource) Next() (*Entry, error) {
+	for s.scanner.Scan() {
+		if strings.TrimSpace(s.scanner.Text()) == "" {
+			continue
+		}
+		s.index++
+
+		var exported service.ExportedURL
+		if err := json.Unmarshal(s.scanner.Bytes(), &exported); err != nil {
+			return nil, &EntryError{Index: s.index, Err: fmt.Errorf("invalid JSON: %w", err)}
+		}
+		if exported.Domain == "" {
+			exported.Domain = s.domain
+		}
+		// Exports keep the exemption of exempt links; the flag adds it to the rest
+		exported.NoExpire = exported.NoExpire || s.noExpire
+		return &Entry{Index: s.index, URL: exported}, nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// jsonSource reads a JSON array of objects, or objects one after another
+type jsonSource struct {
+	decoder *json.Decoder
+	mapper  *mapper
+	array   bool
+	index   int
+}
+
+func newJSONSource(r io.Reader, m *mapper) (*jsonSource, error) {
+	buffered := bufio.NewReader(r)
+	s := &jsonSource{mapper: m}
+
+	// Skip leading whitespace to tell an array from a stream of objects
+	for {
+		b, err := buffered.Peek(1)
+		if err != nil || (b[0] != ' ' && b[0] != '\t' && b[0] != '\n' && b[0] != '\r') {
+			s.array = err == nil && b[0] == '['
+			break
+		}
+		buffered.ReadByte()
+	}
+
+	s.decoder = json.NewDecoder(buffered)
+	s.decoder.UseNumber()
+	if s.array {
+		if _, err := s.decoder.Token(); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	}
+	return s, nil
+}
+
+func (s *jsonSource) Next() (*Entry, error) {
+	if s.array && !s.decoder.More() {
+		return nil, io.EOF
+	}
+
+	var object map[string]interface{}
+	if err := s.decoder.Decode(&object); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		// The decoder cannot resynchronize after a syntax error
+		return nil, fmt.Errorf("entry %d: invalid JSON: %w", s.index+1, err)
+	}
+	s.index++
+
+	row := make(map[string]string, len(object))
+	for key, value := range object {
+		switch v := value.(type) {
+		case string:
+			row[normalizeColumn(key)] = v
+		case json.Number:
+			row[normalizeColumn(key)] = v.String()
+		}
+	}
+
+	exported, err := s.mapper.toURL(row)
+	if err != nil {
+		return nil, &EntryError{Index: s.index, Err: err}
+	}
+	return &Entry{Index: s.index, URL: exported}, nil
+}
@@ -0,0 +1,343 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// yourlsTableColumns is the column order of the YOURLS url table, used for
+// INSERT statements that do not list their columns
+var yourlsTableColumns = []string{"keyword", "url", "title", "timestamp", "ip", "clicks"}
+
+// sqlSource reads the rows of INSERT statements into the YOURLS url table
+// ("yourls_url", or any table ending in "_url") from a mysqldump file.
+// Other statements are skipped.
+type sqlSource struct {
+	lexer  *sqlLexer
+	mapper *mapper
+	rows   []map[string]string
+	index  int
+}
+
+func newSQLSource(r io.Reader, m *mapper) (*sqlSource, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlSource{lexer: &sqlLexer{input: string(data)}, mapper: m}, nil
+}
+
+func (s *sqlSource) Next() (*Entry, error) {
+	for len(s.rows) == 0 {
+		tok, err := s.lexer.next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case tok.kind == tokenEOF:
+			return nil, io.EOF
+		case tok.isWord("INSERT"):
+			if err := s.readInsert(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	s.index++
+
+	exported, err := s.mapper.toURL(row)
+	if err != nil {
+		return nil, &EntryError{Index: s.index, Err: err}
+	}
+	return &Entry{Index: s.index, URL: exported}, nil
+}
+
+// readInsert parses an INSERT statement after its first keyword and queues
+// its rows if it targets the url table
+func (s *sqlSource) readInsert() error {
+	// Skip modifiers such as IGNORE up to INTO
+	for {
+		tok, err := s.lexer.next()
+		if err != nil {
+			return err
+		}
+		if tok.kind == tokenEOF || tok.is(";") {
+			return nil
+		}
+		if tok.isWord("INTO") {
+			break
+		}
+	}
+
+	table, err := s.lexer.next()
+	if err != nil {
+		return err
+	}
+	tok, err := s.lexer.next()
+	if err != nil {
+		return err
+	}
+	if tok.is(".") {
+		// Qualified with the database name
+		if table, err = s.lexer.next(); err != nil {
+			return err
+		}
+		if tok, err = s.lexer.next(); err != nil {
+			return err
+		}
+	}
+
+	name := strings.ToLower(table.text)
+	if table.kind != tokenWord && table.kind != tokenIdent || (name != "url" && !strings.HasSuffix(name, "_url")) {
+		return s.skipStatement()
+	}
+
+	columns := yourlsTableColumns
+	if tok.is("(") {
+		if columns, err = s.readColumns(); err != nil {
+			return err
+		}
+		if tok, err = s.lexer.next(); err != nil {
+			return err
+		}
+	}
+	if !tok.isWord("VALUES") && !tok.isWord("VALUE") {
+		return fmt.Errorf("line %d: expected VALUES in INSERT into %s", s.lexer.line(), table.text)
+	}
+
+	for {
+		values, err := s.readTuple()
+		if err != nil {
+			return err
+		}
+		row := make(map[string]string, len(columns))
+		for i, column := range columns {
+			if i < len(values) {
+				row[column] = values[i]
+			}
+		}
+		s.rows = append(s.rows, row)
+
+		tok, err := s.lexer.next()
+		if err != nil {
+			return err
+		}
+		if !tok.is(",") {
+			if tok.kind == tokenEOF || tok.is(";") {
+				return nil
+			}
+			// e.g. ON DUPLICATE KEY UPDATE
+			return s.skipStatement()
+		}
+	}
+}
+
+// readColumns reads a column list after its opening parenthesis
+func (s *sqlSource) readColumns() ([]string, error) {
+	var columns []string
+	for {
+		tok, err := s.lexer.next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case tok.is(")"):
+			return columns, nil
+		case tok.is(","):
+		case tok.kind == tokenWord || tok.kind == tokenIdent:
+			columns = append(columns, normalizeColumn(tok.text))
+		default:
+			return nil, fmt.Errorf("line %d: unexpected %q in column list", s.lexer.line(), tok.text)
+		}
+	}
+}
+
+// readTuple reads a parenthesized list of values. NULL becomes an empty
+// string.
+func (s *sqlSource) readTuple() ([]string, error) {
+	tok, err := s.lexer.next()
+	if err != nil {
+		return nil, err
+	}
+	if !tok.is("(") {
+		return nil, fmt.Errorf("line %d: expected ( before values", s.lexer.line())
+	}
+
+	var values []string
+	for {
+		tok, err := s.lexer.next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case tok.is(")"):
+			return values, nil
+		case tok.is(","):
+		case tok.kind == tokenString:
+			values = append(values, tok.text)
+		case tok.isWord("NULL"):
+			values = append(values, "")
+		case tok.kind == tokenWord:
+			values = append(values, tok.text)
+		default:
+			return nil, fmt.Errorf("line %d: unexpected %q in values", s.lexer.line(), tok.text)
+		}
+	}
+}
+
+// skipStatement skips tokens up to the end of the current statement
+func (s *sqlSource) skipStatement() error {
+	for {
+		tok, err := s.lexer.next()
+		if err != nil {
+			return err
+		}
+		if tok.kind == tokenEOF || tok.is(";") {
+			return nil
+		}
+	}
+}
+
+// Token kinds
+const (
+	tokenEOF    = iota
+	tokenWord   // keyword, unquoted identifier or number
+	tokenIdent  // `quoted identifier`
+	tokenString // 'string' or "string", unescaped
+	tokenPunct  // ( ) , ; .
+)
+
+type sqlToken struct {
+	kind int
+	text string
+}
+
+func (t sqlToken) is(punct string) bool {
+	return t.kind == tokenPunct && t.text == punct
+}
+
+func (t sqlToken) isWord(word string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, word)
+}
+
+// sqlLexer splits MySQL statements into tokens, skipping whitespace and
+// comments
+type sqlLexer struct {
+	input string
+	pos   int
+}
+
+// line returns the current line number, for error messages
+func (l *sqlLexer) line() int {
+	return strings.Count(l.input[:l.pos], "\n") + 1
+}
+
+func (l *sqlLexer) next() (sqlToken, error) {
+	if err := l.skipSpace(); err != nil {
+		return sqlToken{}, err
+	}
+	if l.pos >= len(l.input) {
+		return sqlToken{kind: tokenEOF}, nil
+	}
+
+	c := l.input[l.pos]
+	switch {
+	case c == '\'' || c == '"':
+		return l.readString(c)
+	case c == '`':
+		end := strings.IndexByte(l.input[l.pos+1:], '`')
+		if end < 0 {
+			return sqlToken{}, fmt.Errorf("line %d: unterminated identifier", l.line())
+		}
+		text := l.input[l.pos+1 : l.pos+1+end]
+		l.pos += end + 2
+		return sqlToken{kind: tokenIdent, text: text}, nil
+	case strings.IndexByte("(),;.", c) >= 0:
+		l.pos++
+		return sqlToken{kind: tokenPunct, text: string(c)}, nil
+	}
+
+	start := l.pos
+	for l.pos < len(l.input) && !isSpace(l.input[l.pos]) && strings.IndexByte("(),;'\"`", l.input[l.pos]) < 0 {
+		// Dots separate qualified names but belong to decimal numbers
+		if l.input[l.pos] == '.' && !isDigit(l.input[start]) {
+			break
+		}
+		l.pos++
+	}
+	return sqlToken{kind: tokenWord, text: l.input[start:l.pos]}, nil
+}
+
+// skipSpace skips whitespace and comments
+func (l *sqlLexer) skipSpace() error {
+	for l.pos < len(l.input) {
+		rest := l.input[l.pos:]
+		switch {
+		case isSpace(rest[0]):
+			l.pos++
+		case strings.HasPrefix(rest, "--") || rest[0] == '#':
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			l.pos += end
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				return fmt.Errorf("line %d: unterminated comment", l.line())
+			}
+			l.pos += end + 4
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// readString reads a quoted string, undoing backslash escapes and doubled
+// quotes
+func (l *sqlLexer) readString(quote byte) (sqlToken, error) {
+	start := l.line()
+	var b strings.Builder
+	for i := l.pos + 1; i < len(l.input); i++ {
+		c := l.input[i]
+		switch {
+		case c == '\\' && i+1 < len(l.input):
+			i++
+			switch l.input[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '0':
+				b.WriteByte(0)
+			case 'Z':
+				b.WriteByte(26)
+			default:
+				b.WriteByte(l.input[i])
+			}
+		case c == quote && i+1 < len(l.input) && l.input[i+1] == quote:
+			b.WriteByte(quote)
+			i++
+		case c == quote:
+			l.pos = i + 1
+			return sqlToken{kind: tokenString, text: b.String()}, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return sqlToken{}, fmt.Errorf("line %d: unterminated string", start)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,208 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// importedLink is the part of an entry the tests compare
+type importedLink struct {
+	code    string
+	url     string
+	title   string
+	created time.Time
+	visits  int
+}
+
+// readEntries reads a source to the end. Entry errors are collected by index;
+// other errors fail the test.
+func readEntries(t *testing.T, source Source) ([]importedLink, []int) {
+	t.Helper()
+
+	var links []importedLink
+	var failed []int
+	for {
+		entry, err := source.Next()
+		if err == io.EOF {
+			return links, failed
+		}
+		var entryErr *EntryError
+		if errors.As(err, &entryErr) {
+			failed = append(failed, entryErr.Index)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		links = append(links, importedLink{
+			code:    entry.URL.ShortCode,
+			url:     entry.URL.OriginalURL,
+			title:   entry.URL.Title,
+			created: entry.URL.CreatedAt,
+			visits:  entry.URL.Visits,
+		})
+	}
+}
+
+func TestYOURLSSQLSource(t *testing.T) {
+	may1 := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		sql  string
+		want []importedLink
+	}{
+		{
+			name: "mysqldump",
+			sql: `-- MySQL dump 10.13
+/*!40101 SET NAMES utf8mb4 */;
+DROP TABLE IF EXISTS ` + "`yourls_url`" + `;
+CREATE TABLE ` + "`yourls_url`" + ` (
+  ` + "`keyword`" + ` varchar(100) NOT NULL,
+  ` + "`url`" + ` text NOT NULL,
+  PRIMARY KEY (` + "`keyword`" + `)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+LOCK TABLES ` + "`yourls_url`" + ` WRITE;
+INSERT INTO ` + "`yourls_url`" + ` VALUES ('abc','https://example.com/a','Page A','2019-05-01 10:00:00','127.0.0.1',12);
+UNLOCK TABLES;`,
+			want: []importedLink{{"abc", "https://example.com/a", "Page A", may1, 12}},
+		},
+		{
+			name: "multi-row values",
+			sql: "INSERT INTO `yourls_url` VALUES ('a1','https://example.com/1','One','2019-05-01 10:00:00','1.2.3.4',1)," +
+				"\n('a2','https://example.com/2',NULL,'2019-05-01 10:00:00','::1',0),('a3','https://example.com/3','','2019-05-01 10:00:00','',3);",
+			want: []importedLink{
+				{"a1", "https://example.com/1", "One", may1, 1},
+				{"a2", "https://example.com/2", "", may1, 0},
+				{"a3", "https://example.com/3", "", may1, 3},
+			},
+		},
+		{
+			name: "escaped quotes and backslashes",
+			sql:  `INSERT INTO yourls_url VALUES ('esc','https://example.com/?q=it\'s','It\'s \"quoted\", O''Brien, C:\\path\\','2019-05-01 10:00:00','',0);`,
+			want: []importedLink{{"esc", "https://example.com/?q=it's", `It's "quoted", O'Brien, C:\path\`, may1, 0}},
+		},
+		{
+			name: "double-quoted strings and punctuation in values",
+			sql:  `INSERT INTO yourls_url VALUES ("dq","https://example.com/a,b;c(d)","Say ""hi"" -- not a comment",'2019-05-01 10:00:00','',0);`,
+			want: []importedLink{{"dq", "https://example.com/a,b;c(d)", `Say "hi" -- not a comment`, may1, 0}},
+		},
+		{
+			name: "escape sequences",
+			sql:  `INSERT INTO yourls_url VALUES ('seq','https://example.com/','Line\nbreak\ttab','2019-05-01 10:00:00','',0);`,
+			want: []importedLink{{"seq", "https://example.com/", "Line\nbreak\ttab", may1, 0}},
+		},
+		{
+			name: "database-qualified backquoted table",
+			sql:  "INSERT INTO `shortener`.`yourls_url` VALUES ('db1','https://example.com/db','','2019-05-01 10:00:00','',2);",
+			want: []importedLink{{"db1", "https://example.com/db", "", may1, 2}},
+		},
+		{
+			name: "database-qualified bare table",
+			sql:  "INSERT INTO shortener.yourls_url VALUES ('db2','https://example.com/db','','2019-05-01 10:00:00','',2);",
+			want: []importedLink{{"db2", "https://example.com/db", "", may1, 2}},
+		},
+		{
+			name: "custom table prefix and modifiers",
+			sql:  "INSERT IGNORE INTO `shrt_url` VALUES ('pre','https://example.com/p','','2019-05-01 10:00:00','',4);",
+			want: []importedLink{{"pre", "https://example.com/p", "", may1, 4}},
+		},
+		{
+			name: "column list in another order",
+			sql:  "INSERT INTO `yourls_url` (`url`, `clicks`, `keyword`, `timestamp`) VALUES ('https://example.com/c',5,'col','2019-05-01 10:00:00'),('https://example.com/d',6,'col2','2019-05-01 10:00:00');",
+			want: []importedLink{
+				{"col", "https://example.com/c", "", may1, 5},
+				{"col2", "https://example.com/d", "", may1, 6},
+			},
+		},
+		{
+			name: "other tables are skipped",
+			sql: "INSERT INTO `yourls_log` VALUES (1,'2019-05-01 10:00:00','abc','https://ref.example','UA','1.2.3.4','US');\n" +
+				"INSERT INTO `yourls_options` VALUES (1,'version','1.9');\n" +
+				"# comment\nINSERT INTO `yourls_url` VALUES ('keep','https://example.com/k','','2019-05-01 10:00:00','',0);",
+			want: []importedLink{{"keep", "https://example.com/k", "", may1, 0}},
+		},
+		{
+			name: "trailing clause",
+			sql:  "INSERT INTO yourls_url VALUES ('dup','https://example.com/x','','2019-05-01 10:00:00','',1) ON DUPLICATE KEY UPDATE clicks = clicks + 1;\nINSERT INTO yourls_url VALUES ('next','https://example.com/y','','2019-05-01 10:00:00','',0);",
+			want: []importedLink{
+				{"dup", "https://example.com/x", "", may1, 1},
+				{"next", "https://example.com/y", "", may1, 0},
+			},
+		},
+		{
+			name: "unix timestamp",
+			sql:  "INSERT INTO yourls_url VALUES ('unix','https://example.com/u','','1556704800','',0);",
+			want: []importedLink{{"unix", "https://example.com/u", "", may1, 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := NewSource(strings.NewReader(tt.sql), Options{Format: FormatYOURLSSQL})
+			if err != nil {
+				t.Fatalf("NewSource() error = %v", err)
+			}
+			got, failed := readEntries(t, source)
+			if len(failed) > 0 {
+				t.Fatalf("entries %v failed", failed)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("read %d links, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("link %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestYOURLSSQLSourceErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+	}{
+		{"unterminated string", "INSERT INTO yourls_url VALUES ('abc','https://example.com/"},
+		{"unterminated identifier", "INSERT INTO `yourls_url VALUES ('abc')"},
+		{"unterminated comment", "/* dump\nINSERT INTO yourls_url VALUES ('abc','https://example.com/');"},
+		{"missing values", "INSERT INTO yourls_url SELECT * FROM other;"},
+		{"unexpected token in values", "INSERT INTO yourls_url VALUES ('abc', ;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := NewSource(strings.NewReader(tt.sql), Options{Format: FormatYOURLSSQL})
+			if err != nil {
+				t.Fatalf("NewSource() error = %v", err)
+			}
+			_, err = source.Next()
+			var entryErr *EntryError
+			if err == nil || err == io.EOF || errors.As(err, &entryErr) {
+				t.Fatalf("Next() error = %v, want a parse error", err)
+			}
+		})
+	}
+}
+
+func TestYOURLSSQLSourceEntryErrors(t *testing.T) {
+	sql := "INSERT INTO yourls_url VALUES ('','https://example.com/','','2019-05-01 10:00:00','',0)," +
+		"('bad','https://example.com/','','yesterday','',0)," +
+		"('ok','https://example.com/','','2019-05-01 10:00:00','',0);"
+
+	source, err := NewSource(strings.NewReader(sql), Options{Format: FormatYOURLSSQL})
+	if err != nil {
+		t.Fatalf("NewSource() error = %v", err)
+	}
+	got, failed := readEntries(t, source)
+	if len(got) != 1 || got[0].code != "ok" {
+		t.Errorf("read %+v, want only ok", got)
+	}
+	if len(failed) != 2 || failed[0] != 1 || failed[1] != 2 {
+		t.Errorf("failed entries = %v, want [1 2]", failed)
+	}
+}
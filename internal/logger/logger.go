@@ -1,6 +1,9 @@
 package logger
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"os"
 	"time"
 
@@ -9,6 +12,16 @@ import (
 
 var log zerolog.Logger
 
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// Options controls the format, level and destination of log lines
+type Options struct {
+	Format string // "console" or "json"
+	Level  string // "debug", "info", "warn" or "error"
+	Output string // "stdout", "stderr" or a file path
+}
+
 func Init() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 
@@ -17,35 +30,107 @@ func Init() {
 		TimeFormat: time.RFC3339,
 	}
 
-	log = zerolog.New(output).
+	log = newLogger(output)
+}
+
+// Configure replaces the default console logger with one built from opts
+func Configure(opts Options) error {
+	var out io.Writer
+	switch opts.Output {
+	case "", "stdout":
+		out = os.Stdout
+	case "stderr":
+		out = os.Stderr
+	default:
+		f, err := os.OpenFile(opts.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open log output: %w", err)
+		}
+		out = f
+	}
+
+	switch opts.Format {
+	case "", "console":
+		out = zerolog.ConsoleWriter{
+			Out:        out,
+			TimeFormat: time.RFC3339,
+			NoColor:    out != os.Stdout && out != os.Stderr,
+		}
+	case "json":
+	default:
+		return fmt.Errorf("unknown log format %q", opts.Format)
+	}
+
+	level := zerolog.InfoLevel
+	if opts.Level != "" {
+		parsed, err := zerolog.ParseLevel(opts.Level)
+		if err != nil {
+			return fmt.Errorf("unknown log level %q", opts.Level)
+		}
+		level = parsed
+	}
+
+	log = newLogger(out).Level(level)
+	return nil
+}
+
+// newLogger creates a logger that reports the caller of the package functions
+func newLogger(out io.Writer) zerolog.Logger {
+	return zerolog.New(out).
 		With().
 		Timestamp().
-		Caller().
+		CallerWithSkipFrameCount(zerolog.CallerSkipFrameCount + 2).
 		Logger()
 }
 
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, if any
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
 func Info(message string, fields ...map[string]interface{}) {
-	event := log.Info()
-	if len(fields) > 0 {
-		for key, value := range fields[0] {
-			event = event.Interface(key, value)
-		}
-	}
-	event.Msg(message)
+	send(log.Info(), message, fields)
 }
 
 func Error(err error, message string, fields ...map[string]interface{}) {
-	event := log.Error().Err(err)
-	if len(fields) > 0 {
-		for key, value := range fields[0] {
-			event = event.Interface(key, value)
-		}
-	}
-	event.Msg(message)
+	send(log.Error().Err(err), message, fields)
 }
 
 func Debug(message string, fields ...map[string]interface{}) {
-	event := log.Debug()
+	send(log.Debug(), message, fields)
+}
+
+// InfoContext logs like Info, adding the request ID carried by ctx
+func InfoContext(ctx context.Context, message string, fields ...map[string]interface{}) {
+	send(withRequestID(ctx, log.Info()), message, fields)
+}
+
+// ErrorContext logs like Error, adding the request ID carried by ctx
+func ErrorContext(ctx context.Context, err error, message string, fields ...map[string]interface{}) {
+	send(withRequestID(ctx, log.Error().Err(err)), message, fields)
+}
+
+// DebugContext logs like Debug, adding the request ID carried by ctx
+func DebugContext(ctx context.Context, message string, fields ...map[string]interface{}) {
+	send(withRequestID(ctx, log.Debug()), message, fields)
+}
+
+// withRequestID adds the request ID carried by ctx to event
+func withRequestID(ctx context.Context, event *zerolog.Event) *zerolog.Event {
+	if id := RequestID(ctx); id != "" {
+		event = event.Str("request_id", id)
+	}
+	return event
+}
+
+// send adds fields to event and writes it
+func send(event *zerolog.Event, message string, fields []map[string]interface{}) {
 	if len(fields) > 0 {
 		for key, value := range fields[0] {
 			event = event.Interface(key, value)
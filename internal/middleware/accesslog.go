@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	customLogger "github.com/nijaru/nano-link/internal/logger"
+)
+
+// AccessLog logs every request with its status and latency. Errors are
+// rendered here so the final status code is logged.
+func AccessLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				c.Status(fiber.StatusInternalServerError)
+			}
+		}
+
+		customLogger.InfoContext(c.UserContext(), "Request", map[string]interface{}{
+			"method":     c.Method(),
+			"path":       c.Path(),
+			"route":      c.Route().Path,
+			"status":     c.Response().StatusCode(),
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"ip":         ClientIP(c),
+			"bytes":      len(c.Response().Body()),
+			"user_agent": c.Get(fiber.HeaderUserAgent),
+		})
+		return nil
+	}
+}
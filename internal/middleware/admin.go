@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	appErrors "github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+)
+
+// KeyAuthenticator checks API key secrets
+type KeyAuthenticator interface {
+	Authenticate(ctx context.Context, secret string) (*models.APIKey, error)
+}
+
+// AdminAuth requires the admin token or an active API key as a bearer token.
+// An empty token is never accepted, so API keys alone can enable the admin
+// endpoints; with no token and no keys every request is refused.
+func AdminAuth(token string, keys KeyAuthenticator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		given, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if ok && token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+			return c.Next()
+		}
+		if ok && keys != nil {
+			key, err := keys.Authenticate(c.UserContext(), given)
+			if err == nil {
+				c.Locals("api_key", key)
+				return c.Next()
+			}
+			if !errors.Is(err, appErrors.ErrForbidden) {
+				return err
+			}
+		}
+
+		c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid admin token")
+	}
+}
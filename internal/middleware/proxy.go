@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// clientIPKey is the Locals key holding the resolved client IP
+const clientIPKey = "client_ip"
+
+// TrustedProxies is a set of proxy addresses whose forwarding headers are believed
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses a list of IP addresses and CIDR ranges
+func ParseTrustedProxies(entries []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies, nil
+}
+
+// Contains reports whether ip belongs to a trusted proxy
+func (p TrustedProxies) Contains(ip net.IP) bool {
+	for _, ipNet := range p {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RealIP resolves the client IP of each request and stores it for ClientIP.
+// X-Forwarded-For is only read when the peer is a trusted proxy, and is walked
+// from the right so addresses prepended by the client cannot be spoofed.
+func RealIP(proxies TrustedProxies) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(clientIPKey, proxies.clientIP(c))
+		return c.Next()
+	}
+}
+
+// ClientIP returns the client IP resolved by RealIP, or the peer address
+func ClientIP(c *fiber.Ctx) string {
+	if ip, ok := c.Locals(clientIPKey).(string); ok {
+		return ip
+	}
+	return c.IP()
+}
+
+// clientIP returns the first untrusted address in the forwarding chain
+func (p TrustedProxies) clientIP(c *fiber.Ctx) string {
+	remote := c.Context().RemoteIP()
+	if !p.Contains(remote) {
+		return remote.String()
+	}
+
+	client := remote
+	hops := strings.Split(c.Get(fiber.HeaderXForwardedFor), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !p.Contains(ip) {
+			break
+		}
+	}
+	return client.String()
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/limiter"
 	customLogger "github.com/nijaru/nano-link/internal/logger"
 	"github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/metrics"
 )
 
 // RateLimit creates a new rate limiting middleware
@@ -36,22 +37,14 @@ func RateLimit(max int, window time.Duration) fiber.Handler {
 	return limiter.New(limiter.Config{
 		Max:        max,
 		Expiration: window,
-		// Consider using X-Forwarded-For or X-Real-IP headers for proxied requests
-		KeyGenerator: func(c *fiber.Ctx) string {
-			// First try X-Forwarded-For and X-Real-IP headers for proxied requests
-			if ip := c.Get("X-Forwarded-For"); ip != "" {
-				return ip
-			}
-			if ip := c.Get("X-Real-IP"); ip != "" {
-				return ip
-			}
-			// Fall back to direct IP
-			return c.IP()
-		},
+		// Key on the client IP resolved through trusted proxies by RealIP
+		KeyGenerator: ClientIP,
 		LimitReached: func(c *fiber.Ctx) error {
+			metrics.RateLimited.Inc()
+
 			// Log rate limit exceeded
-			customLogger.Debug("Rate limit exceeded", map[string]interface{}{
-				"ip":     c.IP(),
+			customLogger.DebugContext(c.UserContext(), "Rate limit exceeded", map[string]interface{}{
+				"ip":     ClientIP(c),
 				"path":   c.Path(),
 				"method": c.Method(),
 			})
@@ -64,8 +57,10 @@ func RateLimit(max int, window time.Duration) fiber.Handler {
 		SkipFailedRequests: false,
 		SkipSuccessfulRequests: false,
 		Next: func(c *fiber.Ctx) bool {
-			// Skip rate limiting for static files
-			return c.Path() == "/" || 
+			// Skip rate limiting for static files and probes
+			return c.Path() == "/" ||
+				   c.Path() == "/livez" ||
+				   c.Path() == "/readyz" || 
 				   c.Path() == "/favicon.ico" || 
 				   c.Method() == fiber.MethodGet && c.Path() == "/static"
 		},
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+
+	customLogger "github.com/nijaru/nano-link/internal/logger"
+)
+
+// requestIDKey is the Locals key of the request ID
+const requestIDKey = "request_id"
+
+// requestIDRegex matches request IDs accepted from clients and proxies
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID assigns every request an ID, reusing a well-formed X-Request-ID
+// header from the client or proxy. The ID is echoed in the response and
+// carried by c.UserContext(), so every log line of the request includes it.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(fiber.HeaderXRequestID)
+		if !requestIDRegex.MatchString(id) {
+			id = utils.UUIDv4()
+		} else {
+			id = utils.CopyString(id)
+		}
+
+		c.Locals(requestIDKey, id)
+		c.Set(fiber.HeaderXRequestID, id)
+		c.SetUserContext(customLogger.WithRequestID(c.UserContext(), id))
+		return c.Next()
+	}
+}
+
+// GetRequestID returns the ID assigned to the request by RequestID
+func GetRequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(requestIDKey).(string)
+	return id
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Collection is a public landing page listing several short URLs
+type Collection struct {
+	ID          int64             `json:"id"`
+	Slug        string            `json:"slug"`
+	Title       string            `json:"title"`
+	Description string            `json:"description,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Items       []*CollectionItem `json:"items"`
+}
+
+// CollectionItem is a short URL listed in a collection
+type CollectionItem struct {
+	ID           int64     `json:"id"`
+	CollectionID int64     `json:"-"`
+	URLID        int64     `json:"-"`
+	ShortCode    string    `json:"short_code"`
+	Domain       string    `json:"domain,omitempty"`
+	Title        string    `json:"title"`
+	Icon         string    `json:"icon,omitempty"` // image URL or a short text/emoji
+	Position     int       `json:"position"`
+	Clicks       int64     `json:"clicks"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CollectionResponse is a collection with public links for the page and each item
+type CollectionResponse struct {
+	*Collection
+	PageURL string                   `json:"page_url"`
+	Items   []CollectionItemResponse `json:"items"`
+}
+
+// CollectionItemResponse is a collection item with its public links
+type CollectionItemResponse struct {
+	*CollectionItem
+	Link     string `json:"link"` // tracked link through the collection page
+	ShortURL string `json:"short_url"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Domain is a custom host serving its own namespace of short codes
+type Domain struct {
+	ID        int64     `json:"id"`
+	Host      string    `json:"host"`
+	CreatedAt time.Time `json:"created_at"`
+}
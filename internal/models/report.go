@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Report statuses
+const (
+	ReportOpen      = "open"
+	ReportDismissed = "dismissed"
+	ReportConfirmed = "confirmed"
+)
+
+// Report is a public report flagging a short URL as abusive
+type Report struct {
+	ID            int64      `json:"id"`
+	URLID         int64      `json:"-"`
+	ShortCode     string     `json:"short_code"`
+	Domain        string     `json:"domain,omitempty"`
+	Destination   string     `json:"destination"`
+	Reason        string     `json:"reason"`
+	Details       string     `json:"details,omitempty"`
+	ReporterEmail string     `json:"reporter_email,omitempty"`
+	ReporterIP    string     `json:"reporter_ip"`
+	UserAgent     string     `json:"user_agent,omitempty"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// TagStats aggregates the links carrying one tag
+type TagStats struct {
+	Tag    string `json:"tag"`
+	Links  int64  `json:"links"`
+	Visits int64  `json:"visits"`
+}
+
+// FolderStats aggregates the links in one folder
+type FolderStats struct {
+	Folder string `json:"folder"`
+	Links  int64  `json:"links"`
+	Visits int64  `json:"visits"`
+}
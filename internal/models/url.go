@@ -3,11 +3,36 @@ package models
 import "time"
 
 type URL struct {
-	ID          int64     `json:"id"`
-	OriginalURL string    `json:"original_url"`
-	ShortCode   string    `json:"short_code"`
-	Visits      int       `json:"visits"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID             int64             `json:"id"`
+	OriginalURL    string            `json:"original_url"`
+	ShortCode      string            `json:"short_code"`
+	Visits         int               `json:"visits"`
+	CreatedAt      time.Time         `json:"created_at"`
+	GeoTargets     map[string]string `json:"geo_targets,omitempty"` // country code -> destination
+	Protected      bool              `json:"protected"`
+	PasswordHash   string            `json:"-"`
+	Owner          string            `json:"owner,omitempty"`
+	Interstitial   bool              `json:"interstitial"`
+	FallbackURL    string            `json:"fallback_url,omitempty"` // web page for non-HTTP destinations
+	Domain         string            `json:"domain,omitempty"`       // custom domain; empty for the default domain
+	Disabled       bool              `json:"disabled"`
+	DisabledReason string            `json:"disabled_reason,omitempty"`
+	Health         *LinkHealth       `json:"health,omitempty"` // nil until the destination is first checked
+	LastVisitedAt  *time.Time        `json:"last_visited_at,omitempty"`
+	Tags           []string          `json:"tags,omitempty"`
+	Folder         string            `json:"folder,omitempty"`
+	Title          string            `json:"title,omitempty"`
+	Description    string            `json:"description,omitempty"`
+	Notes          string            `json:"notes,omitempty"`
+	Card           *SocialCard       `json:"card,omitempty"` // nil when no custom card is set
+
+	// MetadataPending is set until the title and description of the
+	// destination page have been fetched
+	MetadataPending bool `json:"-"`
+
+	// NoExpire exempts the link from age-based expiry, as for links imported
+	// from other shorteners with creation dates that predate the import
+	NoExpire bool `json:"-"`
 }
 
 type URLResponse struct {
@@ -20,3 +45,54 @@ type Stats struct {
 	TotalVisits int64  `json:"total_visits"`
 	LastCreated string `json:"last_created,omitempty"`
 }
+
+// SocialCard is the OpenGraph and Twitter card metadata shown when a short
+// link is shared in chat apps and social networks
+type SocialCard struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+}
+
+// Preview describes a short URL for the preview and interstitial pages
+type Preview struct {
+	ShortCode    string    `json:"short_code"`
+	Domain       string    `json:"domain,omitempty"`
+	ShortURL     string    `json:"short_url"`
+	Destination  string    `json:"destination,omitempty"` // empty for protected links
+	FallbackURL  string    `json:"fallback_url,omitempty"`
+	Owner        string    `json:"owner,omitempty"`
+	Visits       int       `json:"visits"`
+	CreatedAt    time.Time `json:"created_at"`
+	Protected    bool      `json:"protected"`
+	Interstitial bool      `json:"interstitial"`
+	Disabled     bool      `json:"disabled"`
+	Countdown    int       `json:"countdown"` // seconds before an interstitial redirects
+	Warning      string    `json:"warning,omitempty"`
+}
+
+// Health filters for link listings
+const (
+	HealthBroken    = "broken"
+	HealthHealthy   = "healthy"
+	HealthUnchecked = "unchecked"
+)
+
+// LinkHealth is the result of the latest check of a link's destination
+type LinkHealth struct {
+	StatusCode int       `json:"status_code"` // 0 when the request failed
+	LatencyMS  int64     `json:"latency_ms"`
+	Error      string    `json:"error,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Broken reports whether the destination failed to respond successfully
+func (h *LinkHealth) Broken() bool {
+	return h != nil && (h.StatusCode == 0 || h.StatusCode >= 400)
+}
+
+// CountryVisits is the number of visits a URL received from one country
+type CountryVisits struct {
+	Country string `json:"country"`
+	Visits  int64  `json:"visits"`
+}
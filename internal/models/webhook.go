@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// Link events delivered to webhooks
+const (
+	EventLinkCreated  = "link.created"
+	EventLinkUpdated  = "link.updated"
+	EventLinkDisabled = "link.disabled"
+	EventLinkEnabled  = "link.enabled"
+	EventLinkDeleted  = "link.deleted"
+	EventLinkExpired  = "link.expired"
+	EventLinkClicked  = "link.clicked"
+)
+
+// LinkEvents lists every link event
+var LinkEvents = []string{
+	EventLinkCreated,
+	EventLinkUpdated,
+	EventLinkDisabled,
+	EventLinkEnabled,
+	EventLinkDeleted,
+	EventLinkExpired,
+	EventLinkClicked,
+}
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead" // retries exhausted
+)
+
+// LinkEvent is something that happened to a link. It is the JSON payload of
+// webhook deliveries.
+type LinkEvent struct {
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	URL        *URL      `json:"url"`
+	Country    string    `json:"country,omitempty"` // visitor country of link.clicked
+}
+
+// Webhook is a subscription to link events. The secret signs deliveries and
+// is only shown when the webhook is created.
+type Webhook struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Subscribed reports whether the webhook receives an event
+func (w *Webhook) Subscribed(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event queued for, or sent to, a webhook
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	WebhookID      int64      `json:"webhook_id"`
+	Event          string     `json:"event"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
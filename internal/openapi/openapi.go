@@ -0,0 +1,10 @@
+// Package openapi holds the OpenAPI document describing the HTTP API. The
+// document is maintained by hand alongside setupRoutes and the handlers.
+package openapi
+
+import _ "embed"
+
+// Spec is the OpenAPI 3 document served at /api/openapi.json
+//
+//go:embed openapi.json
+var Spec []byte
@@ -0,0 +1,155 @@
+package probes
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// checkTimeout bounds every individual check
+const checkTimeout = 2 * time.Second
+
+// Check statuses
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Pinger checks that a dependency is reachable
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Heartbeat reports whether a background task is still making progress
+type Heartbeat interface {
+	Name() string
+	Last() time.Time
+	Check() error
+}
+
+// CheckResult is the outcome of a single check
+type CheckResult struct {
+	Status     string                 `json:"status"`
+	Error      string                 `json:"error,omitempty"`
+	DurationMS float64                `json:"duration_ms"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+// Report is the outcome of a probe
+type Report struct {
+	Status string                  `json:"status"`
+	Checks map[string]*CheckResult `json:"checks"`
+}
+
+// OK reports whether every check passed
+func (r *Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Prober runs the liveness and readiness checks
+type Prober struct {
+	db           Pinger
+	dbPath       string
+	minFreeBytes uint64
+	heartbeats   []Heartbeat
+	shuttingDown atomic.Bool
+}
+
+// NewProber creates a prober for the database at dbPath. Readiness fails when
+// the database directory has less than minFreeBytes available.
+func NewProber(db Pinger, dbPath string, minFreeBytes uint64) *Prober {
+	return &Prober{db: db, dbPath: dbPath, minFreeBytes: minFreeBytes}
+}
+
+// AddHeartbeat adds a background task to the liveness checks
+func (p *Prober) AddHeartbeat(h Heartbeat) {
+	p.heartbeats = append(p.heartbeats, h)
+}
+
+// SetShuttingDown makes readiness fail so load balancers stop sending traffic
+func (p *Prober) SetShuttingDown() {
+	p.shuttingDown.Store(true)
+}
+
+// Live checks that the background tasks are still making progress
+func (p *Prober) Live(ctx context.Context) *Report {
+	report := newReport()
+	for _, h := range p.heartbeats {
+		report.run(ctx, "task:"+h.Name(), func(context.Context) (map[string]interface{}, error) {
+			return map[string]interface{}{"last_beat": h.Last().UTC().Format(time.RFC3339)}, h.Check()
+		})
+	}
+	return report
+}
+
+// Ready checks that the service can handle requests
+func (p *Prober) Ready(ctx context.Context) *Report {
+	report := newReport()
+	report.run(ctx, "shutdown", func(context.Context) (map[string]interface{}, error) {
+		if p.shuttingDown.Load() {
+			return nil, fmt.Errorf("server is shutting down")
+		}
+		return nil, nil
+	})
+	report.run(ctx, "database", func(ctx context.Context) (map[string]interface{}, error) {
+		return nil, p.db.Ping(ctx)
+	})
+	report.run(ctx, "disk", p.checkDisk)
+	return report
+}
+
+// checkDisk checks the free space of the database directory
+func (p *Prober) checkDisk(context.Context) (map[string]interface{}, error) {
+	dir := filepath.Dir(databaseFile(p.dbPath))
+	free, err := freeBytes(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]interface{}{
+		"path":           dir,
+		"free_bytes":     free,
+		"min_free_bytes": p.minFreeBytes,
+	}
+	if free < p.minFreeBytes {
+		return details, fmt.Errorf("only %d bytes free in %s", free, dir)
+	}
+	return details, nil
+}
+
+// databaseFile strips the "file:" prefix and query options from a SQLite DSN
+func databaseFile(dsn string) string {
+	dsn = strings.TrimPrefix(dsn, "file:")
+	if i := strings.IndexByte(dsn, '?'); i >= 0 {
+		dsn = dsn[:i]
+	}
+	return dsn
+}
+
+// newReport creates a passing report without checks
+func newReport() *Report {
+	return &Report{Status: StatusOK, Checks: map[string]*CheckResult{}}
+}
+
+// run runs a single check with a timeout and records its result
+func (r *Report) run(ctx context.Context, name string, check func(context.Context) (map[string]interface{}, error)) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check(ctx)
+	result := &CheckResult{
+		Status:     StatusOK,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+		Details:    details,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+		r.Status = StatusFail
+	}
+	r.Checks[name] = result
+}
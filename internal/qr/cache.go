@@ -0,0 +1,64 @@
+package qr
+
+import (
+	"container/list"
+	"sync"
+)
+
+// cache keeps the most recently used images, up to a fixed number of entries
+type cache struct {
+	mu      sync.Mutex
+	max     int
+	order   *list.List // front is the most recently used
+	entries map[string]*list.Element
+}
+
+// cacheEntry is an element of cache.order
+type cacheEntry struct {
+	key  string
+	data []byte
+}
+
+// newCache creates a cache of up to max entries; 0 disables caching
+func newCache(max int) *cache {
+	return &cache{
+		max:     max,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the image stored under key
+func (c *cache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).data, true
+}
+
+// add stores an image, evicting the least recently used one when full
+func (c *cache) add(key string, data []byte) {
+	if c.max <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		elem.Value.(*cacheEntry).data = data
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, data: data})
+	if c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,293 @@
+// Package qr draws QR codes as PNG or SVG images. Codes are encoded in
+// process, optionally with a logo in the center, and kept in a small cache.
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+
+	// Registered for logo files
+	_ "image/jpeg"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Output formats
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+// Error correction levels, recovering about 7%, 15%, 25% and 30% of the code
+const (
+	LevelLow      = "L"
+	LevelMedium   = "M"
+	LevelQuartile = "Q"
+	LevelHigh     = "H"
+)
+
+// Limits of the options
+const (
+	MinSize   = 64
+	MaxSize   = 2048
+	MaxMargin = 16
+)
+
+// logoRatio is the share of the code's width covered by the logo. Codes
+// with a logo always use LevelHigh, which recovers the hidden modules.
+const logoRatio = 0.2
+
+var levels = map[string]qrcode.RecoveryLevel{
+	LevelLow:      qrcode.Low,
+	LevelMedium:   qrcode.Medium,
+	LevelQuartile: qrcode.High,
+	LevelHigh:     qrcode.Highest,
+}
+
+// Options controls how a QR code is drawn
+type Options struct {
+	Format     string     // FormatPNG or FormatSVG
+	Size       int        // width and height in pixels
+	Margin     int        // quiet zone around the code, in modules
+	Level      string     // error correction level
+	Foreground color.RGBA // color of the dark modules
+	Background color.RGBA
+	Logo       bool // draw the configured logo in the center
+}
+
+// DefaultOptions returns the options used for parameters that are not given
+func DefaultOptions() Options {
+	return Options{
+		Format:     FormatPNG,
+		Size:       256,
+		Margin:     4,
+		Level:      LevelMedium,
+		Foreground: color.RGBA{A: 0xff},
+		Background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+// Validate checks that the options are within their limits
+func (o Options) Validate() error {
+	if o.Format != FormatPNG && o.Format != FormatSVG {
+		return fmt.Errorf("format must be png or svg")
+	}
+	if o.Size < MinSize || o.Size > MaxSize {
+		return fmt.Errorf("size must be between %d and %d", MinSize, MaxSize)
+	}
+	if o.Margin < 0 || o.Margin > MaxMargin {
+		return fmt.Errorf("margin must be between 0 and %d", MaxMargin)
+	}
+	if _, ok := levels[o.Level]; !ok {
+		return fmt.Errorf("level must be L, M, Q or H")
+	}
+	if o.Foreground == o.Background {
+		return fmt.Errorf("foreground and background colors must differ")
+	}
+	return nil
+}
+
+// ParseColor parses a color written as "rrggbb" or "#rrggbb"
+func ParseColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, expected rrggbb", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, expected rrggbb", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// Generator draws QR codes and caches the results
+type Generator struct {
+	logo    image.Image // nil when no logo is configured
+	logoPNG []byte      // logo re-encoded for SVG output
+	cache   *cache
+}
+
+// NewGenerator creates a generator caching up to cacheSize images. The logo
+// file is optional.
+func NewGenerator(logoPath string, cacheSize int) (*Generator, error) {
+	g := &Generator{cache: newCache(cacheSize)}
+	if logoPath == "" {
+		return g, nil
+	}
+
+	f, err := os.Open(logoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open QR logo: %w", err)
+	}
+	defer f.Close()
+	logo, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode QR logo: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, logo); err != nil {
+		return nil, fmt.Errorf("failed to encode QR logo: %w", err)
+	}
+	g.logo = logo
+	g.logoPNG = buf.Bytes()
+	return g, nil
+}
+
+// Generate returns a QR code encoding content, drawn with opts
+func (g *Generator) Generate(content string, opts Options) ([]byte, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Logo {
+		if g.logo == nil {
+			return nil, fmt.Errorf("no logo is configured")
+		}
+		opts.Level = LevelHigh
+	}
+
+	key := fmt.Sprintf("%s|%+v", content, opts)
+	if data, ok := g.cache.get(key); ok {
+		return data, nil
+	}
+
+	code, err := qrcode.New(content, levels[opts.Level])
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+	modules := code.Bitmap()
+
+	// Every module must be at least one pixel wide
+	if len(modules)+2*opts.Margin > opts.Size {
+		return nil, fmt.Errorf("size is too small for this code, use at least %d", len(modules)+2*opts.Margin)
+	}
+
+	var data []byte
+	if opts.Format == FormatSVG {
+		data = g.drawSVG(modules, opts)
+	} else if data, err = g.drawPNG(modules, opts); err != nil {
+		return nil, err
+	}
+
+	g.cache.add(key, data)
+	return data, nil
+}
+
+// layout returns the module size in pixels and the offset of the first
+// module, centering the code when the size is not a multiple of the modules
+func layout(count int, opts Options) (scale, offset int) {
+	total := count + 2*opts.Margin
+	scale = opts.Size / total
+	offset = (opts.Size-scale*total)/2 + opts.Margin*scale
+	return scale, offset
+}
+
+// logoBounds returns the square covered by the logo, in pixels
+func logoBounds(count, scale, offset int) image.Rectangle {
+	width := int(float64(count*scale) * logoRatio)
+	start := offset + (count*scale-width)/2
+	return image.Rect(start, start, start+width, start+width)
+}
+
+func (g *Generator) drawPNG(modules [][]bool, opts Options) ([]byte, error) {
+	scale, offset := layout(len(modules), opts)
+
+	img := image.NewRGBA(image.Rect(0, 0, opts.Size, opts.Size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+	fg := image.NewUniform(opts.Foreground)
+	for y, row := range modules {
+		for x, dark := range row {
+			if dark {
+				r := image.Rect(offset+x*scale, offset+y*scale, offset+(x+1)*scale, offset+(y+1)*scale)
+				draw.Draw(img, r, fg, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	if opts.Logo {
+		bounds := logoBounds(len(modules), scale, offset)
+		pad := bounds.Inset(-scale)
+		draw.Draw(img, pad, image.NewUniform(opts.Background), image.Point{}, draw.Src)
+		draw.Draw(img, bounds, scaleImage(g.logo, bounds.Dx()), image.Point{}, draw.Over)
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (g *Generator) drawSVG(modules [][]bool, opts Options) []byte {
+	scale, offset := layout(len(modules), opts)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, opts.Size, opts.Size)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`, hexColor(opts.Background))
+
+	// One path for every dark module, with horizontal runs merged
+	fmt.Fprintf(&b, `<path fill="%s" d="`, hexColor(opts.Foreground))
+	for y, row := range modules {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			run := 1
+			for x+run < len(row) && row[x+run] {
+				run++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv%dh-%dz", offset+x*scale, offset+y*scale, run*scale, scale, run*scale)
+			x += run - 1
+		}
+	}
+	b.WriteString(`"/>`)
+
+	if opts.Logo {
+		bounds := logoBounds(len(modules), scale, offset)
+		pad := bounds.Inset(-scale)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`,
+			pad.Min.X, pad.Min.Y, pad.Dx(), pad.Dy(), hexColor(opts.Background))
+		fmt.Fprintf(&b, `<image x="%d" y="%d" width="%d" height="%d" preserveAspectRatio="xMidYMid meet" href="data:image/png;base64,%s"/>`,
+			bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy(), base64.StdEncoding.EncodeToString(g.logoPNG))
+	}
+
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
+
+// scaleImage resizes src to fit a size x size square, keeping its aspect
+// ratio, with nearest-neighbor sampling
+func scaleImage(src image.Image, size int) image.Image {
+	sb := src.Bounds()
+	w, h := size, size
+	if sb.Dx() > sb.Dy() {
+		h = size * sb.Dy() / sb.Dx()
+	} else if sb.Dy() > sb.Dx() {
+		w = size * sb.Dx() / sb.Dy()
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	left, top := (size-w)/2, (size-h)/2
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(left+x, top+y, src.At(sb.Min.X+x*sb.Dx()/w, sb.Min.Y+y*sb.Dy()/h))
+		}
+	}
+	return dst
+}
+
+// hexColor formats a color as "#rrggbb"
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
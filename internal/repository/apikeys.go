@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+)
+
+const (
+	// apiKeyColumns lists the columns read by scanAPIKey, in order
+	apiKeyColumns = `id, name, prefix, key_hash, created_at, last_used_at, revoked_at`
+
+	insertAPIKeySQL    = `INSERT INTO api_keys (name, prefix, key_hash, created_at) VALUES (?, ?, ?, datetime(?))`
+	listAPIKeysSQL     = `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id`
+	getAPIKeyByHashSQL = `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = ?`
+	touchAPIKeySQL     = `UPDATE api_keys SET last_used_at = datetime('now') WHERE id = ?`
+	revokeAPIKeySQL    = `UPDATE api_keys SET revoked_at = datetime('now') WHERE id = ? AND revoked_at IS NULL`
+)
+
+// CreateAPIKey stores a new API key
+func (r *SQLiteRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	ctx, done := observe(ctx, "CreateAPIKey")
+	defer done()
+	if key == nil {
+		return errors.NewValidationError("API key cannot be nil")
+	}
+
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+
+	result, err := r.db.ExecContext(
+		ctx,
+		insertAPIKeySQL,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.CreatedAt.UTC().Format("2006-01-02 15:04:05"),
+	)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	key.ID = id
+	return nil
+}
+
+// ListAPIKeys retrieves all API keys, including revoked ones
+func (r *SQLiteRepository) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	ctx, done := observe(ctx, "ListAPIKeys")
+	defer done()
+	rows, err := r.db.QueryContext(ctx, listAPIKeysSQL)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, errors.NewDatabaseError(err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	return keys, nil
+}
+
+// GetAPIKeyByHash retrieves an API key by the hash of its secret
+func (r *SQLiteRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	ctx, done := observe(ctx, "GetAPIKeyByHash")
+	defer done()
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, getAPIKeyByHashSQL, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFoundError("API key not found")
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	return key, nil
+}
+
+// TouchAPIKey records that an API key was just used
+func (r *SQLiteRepository) TouchAPIKey(ctx context.Context, id int64) error {
+	ctx, done := observe(ctx, "TouchAPIKey")
+	defer done()
+	if _, err := r.db.ExecContext(ctx, touchAPIKeySQL, id); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// RevokeAPIKey revokes an API key
+func (r *SQLiteRepository) RevokeAPIKey(ctx context.Context, id int64) error {
+	ctx, done := observe(ctx, "RevokeAPIKey")
+	defer done()
+	result, err := r.db.ExecContext(ctx, revokeAPIKeySQL, id)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "Active API key not found")
+}
+
+// scanAPIKey reads an API key selected with apiKeyColumns
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	var lastUsedAt, revokedAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.KeyHash, &key.CreatedAt, &lastUsedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+)
+
+const (
+	insertCollectionSQL   = `INSERT INTO collections (slug, title, description, created_at) VALUES (?, ?, ?, datetime(?))`
+	getCollectionSQL      = `SELECT id, slug, title, description, created_at FROM collections WHERE slug = ?`
+	checkCollectionSQL    = `SELECT EXISTS(SELECT 1 FROM collections WHERE slug = ?)`
+	deleteCollectionSQL   = `DELETE FROM collections WHERE slug = ?`
+	getCollectionItemsSQL = `
+		SELECT i.id, i.collection_id, i.url_id, u.short_code, u.domain, i.title, i.icon, i.position, i.clicks, i.created_at
+		FROM collection_items i
+		JOIN urls u ON u.id = i.url_id
+		WHERE i.collection_id = ?
+		ORDER BY i.position, i.id
+	`
+	insertCollectionItemSQL = `
+		INSERT INTO collection_items (collection_id, url_id, title, icon, position, created_at)
+		VALUES (?, ?, ?, ?, ?, datetime(?))
+	`
+	nextItemPositionSQL     = `SELECT COALESCE(MAX(position), 0) + 1 FROM collection_items WHERE collection_id = ?`
+	deleteCollectionItemSQL = `DELETE FROM collection_items WHERE collection_id = ? AND id = ?`
+	setItemPositionSQL      = `UPDATE collection_items SET position = ? WHERE collection_id = ? AND id = ?`
+	incrementItemClicksSQL  = `UPDATE collection_items SET clicks = clicks + 1 WHERE collection_id = ? AND id = ?`
+)
+
+// CreateCollection stores a new collection and its items
+func (r *SQLiteRepository) CreateCollection(ctx context.Context, collection *models.Collection) error {
+	ctx, done := observe(ctx, "CreateCollection")
+	defer done()
+	if collection == nil {
+		return errors.NewValidationError("collection cannot be nil")
+	}
+
+	if collection.CreatedAt.IsZero() {
+		collection.CreatedAt = time.Now()
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
+		ctx,
+		insertCollectionSQL,
+		collection.Slug,
+		collection.Title,
+		collection.Description,
+		collection.CreatedAt.UTC().Format("2006-01-02 15:04:05"),
+	)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	for i, item := range collection.Items {
+		item.CollectionID = id
+		item.Position = i + 1
+		if err := insertCollectionItem(ctx, tx, item); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	collection.ID = id
+	return nil
+}
+
+// GetCollection retrieves a collection and its items, ordered by position
+func (r *SQLiteRepository) GetCollection(ctx context.Context, slug string) (*models.Collection, error) {
+	ctx, done := observe(ctx, "GetCollection")
+	defer done()
+	if slug == "" {
+		return nil, errors.NewValidationError("slug cannot be empty")
+	}
+
+	collection := &models.Collection{}
+	err := r.db.QueryRowContext(ctx, getCollectionSQL, slug).Scan(
+		&collection.ID,
+		&collection.Slug,
+		&collection.Title,
+		&collection.Description,
+		&collection.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFoundError("Collection not found")
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, getCollectionItemsSQL, collection.ID)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	defer rows.Close()
+
+	collection.Items = []*models.CollectionItem{}
+	for rows.Next() {
+		item := &models.CollectionItem{}
+		err := rows.Scan(
+			&item.ID,
+			&item.CollectionID,
+			&item.URLID,
+			&item.ShortCode,
+			&item.Domain,
+			&item.Title,
+			&item.Icon,
+			&item.Position,
+			&item.Clicks,
+			&item.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.NewDatabaseError(err)
+		}
+		collection.Items = append(collection.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	return collection, nil
+}
+
+// CollectionExists checks if a collection slug already exists
+func (r *SQLiteRepository) CollectionExists(ctx context.Context, slug string) (bool, error) {
+	ctx, done := observe(ctx, "CollectionExists")
+	defer done()
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, checkCollectionSQL, slug).Scan(&exists); err != nil {
+		return false, errors.NewDatabaseError(err)
+	}
+	return exists, nil
+}
+
+// DeleteCollection deletes a collection and its items
+func (r *SQLiteRepository) DeleteCollection(ctx context.Context, slug string) error {
+	ctx, done := observe(ctx, "DeleteCollection")
+	defer done()
+	result, err := r.db.ExecContext(ctx, deleteCollectionSQL, slug)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "Collection not found")
+}
+
+// AddCollectionItem appends an item to a collection
+func (r *SQLiteRepository) AddCollectionItem(ctx context.Context, item *models.CollectionItem) error {
+	ctx, done := observe(ctx, "AddCollectionItem")
+	defer done()
+	if item == nil {
+		return errors.NewValidationError("item cannot be nil")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRowContext(ctx, nextItemPositionSQL, item.CollectionID).Scan(&item.Position); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	if err := insertCollectionItem(ctx, tx, item); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// DeleteCollectionItem removes an item from a collection
+func (r *SQLiteRepository) DeleteCollectionItem(ctx context.Context, collectionID, itemID int64) error {
+	ctx, done := observe(ctx, "DeleteCollectionItem")
+	defer done()
+	result, err := r.db.ExecContext(ctx, deleteCollectionItemSQL, collectionID, itemID)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "Collection item not found")
+}
+
+// ReorderCollectionItems sets item positions to the order of itemIDs
+func (r *SQLiteRepository) ReorderCollectionItems(ctx context.Context, collectionID int64, itemIDs []int64) error {
+	ctx, done := observe(ctx, "ReorderCollectionItems")
+	defer done()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	defer tx.Rollback()
+
+	for i, itemID := range itemIDs {
+		result, err := tx.ExecContext(ctx, setItemPositionSQL, i+1, collectionID, itemID)
+		if err != nil {
+			return errors.NewDatabaseError(err)
+		}
+		if err := requireRowsAffected(result, "Collection item not found"); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// IncrementItemClicks increments the click counter for a collection item
+func (r *SQLiteRepository) IncrementItemClicks(ctx context.Context, collectionID, itemID int64) error {
+	ctx, done := observe(ctx, "IncrementItemClicks")
+	defer done()
+	result, err := r.db.ExecContext(ctx, incrementItemClicksSQL, collectionID, itemID)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "Collection item not found")
+}
+
+// insertCollectionItem inserts an item within a transaction
+func insertCollectionItem(ctx context.Context, tx *sql.Tx, item *models.CollectionItem) error {
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = time.Now()
+	}
+
+	result, err := tx.ExecContext(
+		ctx,
+		insertCollectionItemSQL,
+		item.CollectionID,
+		item.URLID,
+		item.Title,
+		item.Icon,
+		item.Position,
+		item.CreatedAt.UTC().Format("2006-01-02 15:04:05"),
+	)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	item.ID = id
+	return nil
+}
+
+// requireRowsAffected returns a not found error if a statement changed nothing
+func requireRowsAffected(result sql.Result, notFoundMessage string) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	if rowsAffected == 0 {
+		return errors.NewNotFoundError(notFoundMessage)
+	}
+	return nil
+}
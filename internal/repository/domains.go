@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+)
+
+const (
+	insertDomainSQL = `INSERT INTO domains (host, created_at) VALUES (?, datetime(?))`
+	listDomainsSQL  = `SELECT id, host, created_at FROM domains ORDER BY host`
+	checkDomainSQL  = `SELECT EXISTS(SELECT 1 FROM domains WHERE host = ?)`
+	domainInUseSQL  = `SELECT EXISTS(SELECT 1 FROM urls WHERE domain = ?)`
+	deleteDomainSQL = `DELETE FROM domains WHERE host = ?`
+)
+
+// CreateDomain stores a new custom domain
+func (r *SQLiteRepository) CreateDomain(ctx context.Context, domain *models.Domain) error {
+	ctx, done := observe(ctx, "CreateDomain")
+	defer done()
+	if domain == nil {
+		return errors.NewValidationError("domain cannot be nil")
+	}
+
+	if domain.CreatedAt.IsZero() {
+		domain.CreatedAt = time.Now()
+	}
+
+	result, err := r.db.ExecContext(
+		ctx,
+		insertDomainSQL,
+		domain.Host,
+		domain.CreatedAt.UTC().Format("2006-01-02 15:04:05"),
+	)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	domain.ID = id
+	return nil
+}
+
+// ListDomains retrieves all custom domains
+func (r *SQLiteRepository) ListDomains(ctx context.Context) ([]*models.Domain, error) {
+	ctx, done := observe(ctx, "ListDomains")
+	defer done()
+	rows, err := r.db.QueryContext(ctx, listDomainsSQL)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	defer rows.Close()
+
+	domains := []*models.Domain{}
+	for rows.Next() {
+		domain := &models.Domain{}
+		if err := rows.Scan(&domain.ID, &domain.Host, &domain.CreatedAt); err != nil {
+			return nil, errors.NewDatabaseError(err)
+		}
+		domains = append(domains, domain)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	return domains, nil
+}
+
+// DomainExists checks if a custom domain is registered
+func (r *SQLiteRepository) DomainExists(ctx context.Context, host string) (bool, error) {
+	ctx, done := observe(ctx, "DomainExists")
+	defer done()
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, checkDomainSQL, host).Scan(&exists); err != nil {
+		return false, errors.NewDatabaseError(err)
+	}
+	return exists, nil
+}
+
+// DomainInUse checks if any URL belongs to a custom domain
+func (r *SQLiteRepository) DomainInUse(ctx context.Context, host string) (bool, error) {
+	ctx, done := observe(ctx, "DomainInUse")
+	defer done()
+	var inUse bool
+	if err := r.db.QueryRowContext(ctx, domainInUseSQL, host).Scan(&inUse); err != nil {
+		return false, errors.NewDatabaseError(err)
+	}
+	return inUse, nil
+}
+
+// DeleteDomain deletes a custom domain
+func (r *SQLiteRepository) DeleteDomain(ctx context.Context, host string) error {
+	ctx, done := observe(ctx, "DeleteDomain")
+	defer done()
+	result, err := r.db.ExecContext(ctx, deleteDomainSQL, host)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "Domain not found")
+}
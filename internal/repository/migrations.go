@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nijaru/nano-link/internal/errors"
+)
+
+// migrations holds schema changes applied after createTableSQL. Each entry is
+// applied once, in order, and the count of applied entries is tracked with
+// PRAGMA user_version. Never edit or reorder an existing entry; append instead.
+var migrations = []string{
+	// 1: per-country redirect targets and visit breakdowns
+	`
+		CREATE TABLE IF NOT EXISTS url_geo_targets (
+			url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+			country TEXT NOT NULL,
+			target_url TEXT NOT NULL,
+			PRIMARY KEY (url_id, country)
+		);
+		CREATE TABLE IF NOT EXISTS url_country_visits (
+			url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+			country TEXT NOT NULL,
+			visits INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (url_id, country)
+		);
+	`,
+	// 2: password-protected links
+	`ALTER TABLE urls ADD COLUMN password_hash TEXT NOT NULL DEFAULT ''`,
+	// 3: link owners and per-link interstitial pages
+	`
+		ALTER TABLE urls ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+		ALTER TABLE urls ADD COLUMN interstitial INTEGER NOT NULL DEFAULT 0;
+	`,
+	// 4: web fallbacks for deep links
+	`ALTER TABLE urls ADD COLUMN fallback_url TEXT NOT NULL DEFAULT ''`,
+	// 5: link-in-bio collections
+	`
+		CREATE TABLE IF NOT EXISTS collections (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			slug TEXT UNIQUE NOT NULL,
+			title TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT (datetime('now'))
+		);
+		CREATE TABLE IF NOT EXISTS collection_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			collection_id INTEGER NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+			url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+			title TEXT NOT NULL,
+			icon TEXT NOT NULL DEFAULT '',
+			position INTEGER NOT NULL DEFAULT 0,
+			clicks INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT (datetime('now'))
+		);
+		CREATE INDEX IF NOT EXISTS idx_collection_items_collection ON collection_items(collection_id, position);
+	`,
+	// 6: custom domains with short codes unique per domain. SQLite cannot drop
+	// the inline UNIQUE constraint on short_code, so the table is rebuilt.
+	`
+		CREATE TABLE IF NOT EXISTS domains (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			host TEXT UNIQUE NOT NULL,
+			created_at DATETIME DEFAULT (datetime('now'))
+		);
+		CREATE TABLE urls_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			original_url TEXT NOT NULL,
+			short_code TEXT NOT NULL,
+			visits INTEGER DEFAULT 0,
+			created_at DATETIME DEFAULT (datetime('now')),
+			password_hash TEXT NOT NULL DEFAULT '',
+			owner TEXT NOT NULL DEFAULT '',
+			interstitial INTEGER NOT NULL DEFAULT 0,
+			fallback_url TEXT NOT NULL DEFAULT '',
+			domain TEXT NOT NULL DEFAULT '',
+			UNIQUE (domain, short_code)
+		);
+		INSERT INTO urls_new (id, original_url, short_code, visits, created_at, password_hash, owner, interstitial, fallback_url)
+			SELECT id, original_url, short_code, visits, created_at, password_hash, owner, interstitial, fallback_url FROM urls;
+		DROP TABLE urls;
+		ALTER TABLE urls_new RENAME TO urls;
+		CREATE INDEX IF NOT EXISTS idx_short_code ON urls(short_code);
+		CREATE INDEX IF NOT EXISTS idx_original_url ON urls(original_url);
+	`,
+	// 7: disabled links, e.g. destinations matched by the blocklist
+	`
+		ALTER TABLE urls ADD COLUMN disabled INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE urls ADD COLUMN disabled_reason TEXT NOT NULL DEFAULT '';
+	`,
+	// 8: destination health checks
+	`
+		ALTER TABLE urls ADD COLUMN health_status INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE urls ADD COLUMN health_latency_ms INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE urls ADD COLUMN health_error TEXT NOT NULL DEFAULT '';
+		ALTER TABLE urls ADD COLUMN health_checked_at DATETIME;
+		CREATE INDEX IF NOT EXISTS idx_health ON urls(health_checked_at, health_status);
+	`,
+	// 9: abuse reports
+	`
+		CREATE TABLE IF NOT EXISTS reports (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+			reason TEXT NOT NULL,
+			details TEXT NOT NULL DEFAULT '',
+			reporter_email TEXT NOT NULL DEFAULT '',
+			reporter_ip TEXT NOT NULL,
+			user_agent TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'open',
+			created_at DATETIME DEFAULT (datetime('now')),
+			resolved_at DATETIME
+		);
+		CREATE INDEX IF NOT EXISTS idx_reports_url ON reports(url_id, status);
+		CREATE INDEX IF NOT EXISTS idx_reports_status ON reports(status, created_at);
+	`,
+	// 10: API keys
+	`
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			prefix TEXT NOT NULL,
+			key_hash TEXT UNIQUE NOT NULL,
+			created_at DATETIME DEFAULT (datetime('now')),
+			last_used_at DATETIME,
+			revoked_at DATETIME
+		);
+	`,
+	// 11: listing sorts and filters. destination_host is filled in by
+	// backfillDestinationHosts, since SQLite cannot parse URLs.
+	`
+		ALTER TABLE urls ADD COLUMN last_visited_at DATETIME;
+		ALTER TABLE urls ADD COLUMN destination_host TEXT;
+		CREATE INDEX IF NOT EXISTS idx_urls_created ON urls(created_at, id);
+		CREATE INDEX IF NOT EXISTS idx_urls_visits ON urls(visits, id);
+		CREATE INDEX IF NOT EXISTS idx_urls_last_visited ON urls(COALESCE(last_visited_at, ''), id);
+		CREATE INDEX IF NOT EXISTS idx_urls_owner ON urls(owner);
+		CREATE INDEX IF NOT EXISTS idx_urls_destination_host ON urls(destination_host);
+	`,
+	// 12: tags and folders
+	`
+		CREATE TABLE IF NOT EXISTS url_tags (
+			url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (url_id, tag)
+		);
+		CREATE INDEX IF NOT EXISTS idx_url_tags_tag ON url_tags(tag, url_id);
+		ALTER TABLE urls ADD COLUMN folder TEXT NOT NULL DEFAULT '';
+		CREATE INDEX IF NOT EXISTS idx_urls_folder ON urls(folder);
+	`,
+	// 13: titles, descriptions and notes, optionally fetched from the destination page
+	`
+		ALTER TABLE urls ADD COLUMN title TEXT NOT NULL DEFAULT '';
+		ALTER TABLE urls ADD COLUMN description TEXT NOT NULL DEFAULT '';
+		ALTER TABLE urls ADD COLUMN notes TEXT NOT NULL DEFAULT '';
+		ALTER TABLE urls ADD COLUMN metadata_pending INTEGER NOT NULL DEFAULT 0;
+		CREATE INDEX IF NOT EXISTS idx_urls_metadata_pending ON urls(id) WHERE metadata_pending = 1;
+	`,
+	// 14: custom social preview cards
+	`
+		ALTER TABLE urls ADD COLUMN card_title TEXT NOT NULL DEFAULT '';
+		ALTER TABLE urls ADD COLUMN card_description TEXT NOT NULL DEFAULT '';
+		ALTER TABLE urls ADD COLUMN card_image_url TEXT NOT NULL DEFAULT '';
+	`,
+	// 15: webhook subscriptions and their delivery queue
+	`
+		CREATE TABLE IF NOT EXISTS webhooks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			url TEXT NOT NULL,
+			events TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			created_at DATETIME DEFAULT (datetime('now'))
+		);
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+			event TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at DATETIME NOT NULL DEFAULT (datetime('now')),
+			last_status_code INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT (datetime('now')),
+			delivered_at DATETIME
+		);
+		CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+		CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id);
+	`,
+	// 16: imported links, which are exempt from age-based expiry
+	`
+		ALTER TABLE urls ADD COLUMN imported INTEGER NOT NULL DEFAULT 0;
+	`,
+	// 17: the expiry exemption is chosen per import instead of being given to
+	// every imported link
+	`
+		ALTER TABLE urls RENAME COLUMN imported TO no_expire;
+	`,
+}
+
+// backfillDestinationHosts fills in the destination host of URLs stored
+// before the column existed
+func (r *SQLiteRepository) backfillDestinationHosts(ctx context.Context) error {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, original_url FROM urls WHERE destination_host IS NULL`)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	hosts := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var originalURL string
+		if err := rows.Scan(&id, &originalURL); err != nil {
+			rows.Close()
+			return errors.NewDatabaseError(err)
+		}
+		hosts[id] = destinationHost(originalURL)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	defer tx.Rollback()
+	for id, host := range hosts {
+		if _, err := tx.ExecContext(ctx, `UPDATE urls SET destination_host = ? WHERE id = ?`, host, id); err != nil {
+			return errors.NewDatabaseError(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// SchemaVersion returns the number of migrations applied to the database
+func (r *SQLiteRepository) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	if err := r.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return 0, errors.NewDatabaseError(err)
+	}
+	return version, nil
+}
+
+// migrate applies any pending schema migrations. Foreign keys are disabled on
+// the migration connection so that table rebuilds do not cascade deletes, and
+// are checked for violations before each migration commits.
+func (r *SQLiteRepository) migrate(ctx context.Context) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	defer conn.Close()
+
+	var version int
+	if err := conn.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	if version >= len(migrations) {
+		return nil
+	}
+
+	// foreign_keys cannot be changed inside a transaction
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	defer conn.ExecContext(context.Background(), "PRAGMA foreign_keys = ON")
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return errors.NewDatabaseError(err)
+		}
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return errors.NewDatabaseError(err)
+		}
+
+		rows, err := tx.QueryContext(ctx, "PRAGMA foreign_key_check")
+		if err != nil {
+			tx.Rollback()
+			return errors.NewDatabaseError(err)
+		}
+		violation := rows.Next()
+		rows.Close()
+		if violation {
+			tx.Rollback()
+			return errors.NewDatabaseError(fmt.Errorf("migration %d violates foreign key constraints", i+1))
+		}
+
+		// PRAGMA does not accept bound parameters
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return errors.NewDatabaseError(err)
+		}
+		if err := tx.Commit(); err != nil {
+			return errors.NewDatabaseError(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+)
+
+const (
+	// reportColumns lists the columns read by scanReport, in order
+	reportColumns = `
+		r.id, r.url_id, u.short_code, u.domain, u.original_url, r.reason, r.details,
+		r.reporter_email, r.reporter_ip, r.user_agent, r.status, r.created_at, r.resolved_at
+	`
+	insertReportSQL = `
+		INSERT INTO reports (url_id, reason, details, reporter_email, reporter_ip, user_agent, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, datetime(?))
+	`
+	getReportSQL      = `SELECT ` + reportColumns + ` FROM reports r JOIN urls u ON u.id = r.url_id WHERE r.id = ?`
+	listReportsSQL    = `SELECT ` + reportColumns + ` FROM reports r JOIN urls u ON u.id = r.url_id`
+	hasOpenReportSQL  = `SELECT EXISTS(SELECT 1 FROM reports WHERE url_id = ? AND reporter_ip = ? AND status = 'open')`
+	countReportersSQL = `SELECT COUNT(DISTINCT reporter_ip) FROM reports WHERE url_id = ? AND status = 'open'`
+	resolveReportSQL  = `UPDATE reports SET status = ?, resolved_at = datetime('now') WHERE id = ? AND status = 'open'`
+	resolveOpenSQL    = `UPDATE reports SET status = ?, resolved_at = datetime('now') WHERE url_id = ? AND status = 'open'`
+)
+
+// CreateReport stores a new report
+func (r *SQLiteRepository) CreateReport(ctx context.Context, report *models.Report) error {
+	ctx, done := observe(ctx, "CreateReport")
+	defer done()
+	if report == nil {
+		return errors.NewValidationError("report cannot be nil")
+	}
+
+	if report.CreatedAt.IsZero() {
+		report.CreatedAt = time.Now()
+	}
+	if report.Status == "" {
+		report.Status = models.ReportOpen
+	}
+
+	result, err := r.db.ExecContext(
+		ctx,
+		insertReportSQL,
+		report.URLID,
+		report.Reason,
+		report.Details,
+		report.ReporterEmail,
+		report.ReporterIP,
+		report.UserAgent,
+		report.Status,
+		report.CreatedAt.UTC().Format("2006-01-02 15:04:05"),
+	)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	report.ID = id
+	return nil
+}
+
+// GetReport retrieves a report by ID
+func (r *SQLiteRepository) GetReport(ctx context.Context, id int64) (*models.Report, error) {
+	ctx, done := observe(ctx, "GetReport")
+	defer done()
+	report, err := scanReport(r.db.QueryRowContext(ctx, getReportSQL, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFoundError("Report not found")
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	return report, nil
+}
+
+// ListReports retrieves the newest reports with a status, or all reports if status is empty
+func (r *SQLiteRepository) ListReports(ctx context.Context, status string, limit int) ([]*models.Report, error) {
+	ctx, done := observe(ctx, "ListReports")
+	defer done()
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := listReportsSQL
+	args := []any{}
+	if status != "" {
+		query += ` WHERE r.status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY r.created_at DESC, r.id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	defer rows.Close()
+
+	reports := []*models.Report{}
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, errors.NewDatabaseError(err)
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	return reports, nil
+}
+
+// HasOpenReport checks if a reporter IP already has an open report on a URL
+func (r *SQLiteRepository) HasOpenReport(ctx context.Context, urlID int64, reporterIP string) (bool, error) {
+	ctx, done := observe(ctx, "HasOpenReport")
+	defer done()
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, hasOpenReportSQL, urlID, reporterIP).Scan(&exists); err != nil {
+		return false, errors.NewDatabaseError(err)
+	}
+	return exists, nil
+}
+
+// CountOpenReporters counts the distinct reporter IPs with open reports on a URL
+func (r *SQLiteRepository) CountOpenReporters(ctx context.Context, urlID int64) (int, error) {
+	ctx, done := observe(ctx, "CountOpenReporters")
+	defer done()
+	var count int
+	if err := r.db.QueryRowContext(ctx, countReportersSQL, urlID).Scan(&count); err != nil {
+		return 0, errors.NewDatabaseError(err)
+	}
+	return count, nil
+}
+
+// ResolveReport sets the status of an open report
+func (r *SQLiteRepository) ResolveReport(ctx context.Context, id int64, status string) error {
+	ctx, done := observe(ctx, "ResolveReport")
+	defer done()
+	result, err := r.db.ExecContext(ctx, resolveReportSQL, status, id)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "Open report not found")
+}
+
+// ResolveOpenReports sets the status of every open report on a URL
+func (r *SQLiteRepository) ResolveOpenReports(ctx context.Context, urlID int64, status string) error {
+	ctx, done := observe(ctx, "ResolveOpenReports")
+	defer done()
+	if _, err := r.db.ExecContext(ctx, resolveOpenSQL, status, urlID); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// scanReport reads a report selected with reportColumns
+func scanReport(row rowScanner) (*models.Report, error) {
+	report := &models.Report{}
+	var resolvedAt sql.NullTime
+	err := row.Scan(
+		&report.ID,
+		&report.URLID,
+		&report.ShortCode,
+		&report.Domain,
+		&report.Destination,
+		&report.Reason,
+		&report.Details,
+		&report.ReporterEmail,
+		&report.ReporterIP,
+		&report.UserAgent,
+		&report.Status,
+		&report.CreatedAt,
+		&resolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolvedAt.Valid {
+		report.ResolvedAt = &resolvedAt.Time
+	}
+	return report, nil
+}
@@ -7,32 +7,261 @@ import (
 	"github.com/nijaru/nano-link/internal/models"
 )
 
+// Sort orders for URL listings
+const (
+	SortCreated     = "created"
+	SortVisits      = "visits"
+	SortLastVisited = "last_visited"
+)
+
+// Status filters for URL listings
+const (
+	StatusActive   = "active"
+	StatusDisabled = "disabled"
+)
+
+// URLFilter narrows and orders a listing of URLs
+type URLFilter struct {
+	Limit     int
+	Health    string // models.HealthBroken, HealthHealthy or HealthUnchecked; empty for all
+	Sort      string // SortCreated, SortVisits or SortLastVisited; empty sorts by creation
+	Ascending bool
+
+	Destination   string // destination host, matching its subdomains too
+	Search        string // substring of the destination, short code or title
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Owner         string
+	Status        string // StatusActive or StatusDisabled; empty for all
+	Tag           string
+	Folder        string
+
+	// IncludeProtected matches Destination and Search against the hidden
+	// destinations of password-protected links
+	IncludeProtected bool
+
+	// After continues the listing after the URL the cursor was taken from
+	After *URLCursor
+}
+
+// URLCursor is the position of a URL in a listing's sort order
+type URLCursor struct {
+	Value string // sort key of the URL as stored
+	ID    int64
+}
+
 // URLRepository defines the interface for URL storage operations
 type URLRepository interface {
 	// Create stores a new URL
 	Create(ctx context.Context, url *models.URL) error
 
-	// GetByOriginalURL retrieves a URL by its original URL
-	GetByOriginalURL(ctx context.Context, originalURL string) (*models.URL, error)
+	// GetByOriginalURL retrieves a plain URL on a domain by its original URL
+	GetByOriginalURL(ctx context.Context, domain, originalURL string) (*models.URL, error)
 
-	// GetByCode retrieves a URL by its short code
-	GetByCode(ctx context.Context, code string) (*models.URL, error)
+	// GetByCode retrieves a URL by its domain and short code
+	GetByCode(ctx context.Context, domain, code string) (*models.URL, error)
 
 	// IncrementVisits increments the visit counter for a URL
-	IncrementVisits(ctx context.Context, code string) error
+	IncrementVisits(ctx context.Context, urlID int64) error
 
-	// GetRecentURLs retrieves recent URLs with pagination
-	GetRecentURLs(ctx context.Context, limit int) ([]*models.URL, error)
+	// GetRecentURLs retrieves recent URLs matching a filter
+	GetRecentURLs(ctx context.Context, filter URLFilter) ([]*models.URL, error)
 
 	// GetStats retrieves usage statistics
 	GetStats(ctx context.Context) (*models.Stats, error)
 
-	// CodeExists checks if a short code already exists
-	CodeExists(ctx context.Context, code string) (bool, error)
+	// CodeExists checks if a short code already exists on a domain
+	CodeExists(ctx context.Context, domain, code string) (bool, error)
+
+	// RecordCountryVisit increments the per-country visit counter for a URL
+	RecordCountryVisit(ctx context.Context, urlID int64, country string) error
 
-	// DeleteOldURLs deletes URLs older than the specified age
+	// GetCountryVisits retrieves the per-country visit breakdown for a URL
+	GetCountryVisits(ctx context.Context, urlID int64) ([]*models.CountryVisits, error)
+
+	// DeleteOldURLs deletes URLs older than the specified age, except those
+	// exempt from expiry
 	DeleteOldURLs(ctx context.Context, age time.Duration) (int64, error)
 
+	// CountOldURLs counts the URLs that DeleteOldURLs would delete
+	CountOldURLs(ctx context.Context, age time.Duration) (int64, error)
+
+	// ListOldURLs retrieves up to limit URLs that DeleteOldURLs would delete, in ID order
+	ListOldURLs(ctx context.Context, age time.Duration, limit int) ([]*models.URL, error)
+
+	// ListEnabledURLs retrieves up to limit enabled URLs with IDs above afterID, in ID order
+	ListEnabledURLs(ctx context.Context, afterID int64, limit int) ([]*models.URL, error)
+
+	// ListURLs retrieves up to limit URLs with IDs above afterID, including disabled ones, in ID order
+	ListURLs(ctx context.Context, afterID int64, limit int) ([]*models.URL, error)
+
+	// DeleteURL deletes a URL and everything recorded about it
+	DeleteURL(ctx context.Context, urlID int64) error
+
+	// DisableURL marks a URL as disabled with a reason
+	DisableURL(ctx context.Context, urlID int64, reason string) error
+
+	// EnableURL clears the disabled flag of a URL
+	EnableURL(ctx context.Context, urlID int64) error
+
+	// UpdateURLHealth records the latest destination check of a URL
+	UpdateURLHealth(ctx context.Context, urlID int64, health *models.LinkHealth) error
+
+	// UpdateURLDetails stores the title, description, notes and social card of a URL
+	UpdateURLDetails(ctx context.Context, url *models.URL) error
+
+	// ListPendingMetadata retrieves up to limit URLs with IDs above afterID
+	// whose page metadata has not been fetched yet, in ID order
+	ListPendingMetadata(ctx context.Context, afterID int64, limit int) ([]*models.URL, error)
+
+	// RecordURLMetadata fills in the empty title and description of a URL
+	// and clears its pending flag
+	RecordURLMetadata(ctx context.Context, urlID int64, title, description string) error
+
 	// Close closes the repository connection
 	Close() error
 }
+
+// CollectionRepository defines the interface for collection storage operations
+type CollectionRepository interface {
+	// CreateCollection stores a new collection and its items
+	CreateCollection(ctx context.Context, collection *models.Collection) error
+
+	// GetCollection retrieves a collection and its items, ordered by position
+	GetCollection(ctx context.Context, slug string) (*models.Collection, error)
+
+	// CollectionExists checks if a collection slug already exists
+	CollectionExists(ctx context.Context, slug string) (bool, error)
+
+	// DeleteCollection deletes a collection and its items
+	DeleteCollection(ctx context.Context, slug string) error
+
+	// AddCollectionItem appends an item to a collection
+	AddCollectionItem(ctx context.Context, item *models.CollectionItem) error
+
+	// DeleteCollectionItem removes an item from a collection
+	DeleteCollectionItem(ctx context.Context, collectionID, itemID int64) error
+
+	// ReorderCollectionItems sets item positions to the order of itemIDs
+	ReorderCollectionItems(ctx context.Context, collectionID int64, itemIDs []int64) error
+
+	// IncrementItemClicks increments the click counter for a collection item
+	IncrementItemClicks(ctx context.Context, collectionID, itemID int64) error
+}
+
+// DomainRepository defines the interface for custom domain storage operations
+type DomainRepository interface {
+	// CreateDomain stores a new custom domain
+	CreateDomain(ctx context.Context, domain *models.Domain) error
+
+	// ListDomains retrieves all custom domains
+	ListDomains(ctx context.Context) ([]*models.Domain, error)
+
+	// DomainExists checks if a custom domain is registered
+	DomainExists(ctx context.Context, host string) (bool, error)
+
+	// DomainInUse checks if any URL belongs to a custom domain
+	DomainInUse(ctx context.Context, host string) (bool, error)
+
+	// DeleteDomain deletes a custom domain
+	DeleteDomain(ctx context.Context, host string) error
+}
+
+// ReportRepository defines the interface for abuse report storage operations
+type ReportRepository interface {
+	// CreateReport stores a new report
+	CreateReport(ctx context.Context, report *models.Report) error
+
+	// GetReport retrieves a report by ID
+	GetReport(ctx context.Context, id int64) (*models.Report, error)
+
+	// ListReports retrieves the newest reports with a status, or all reports if status is empty
+	ListReports(ctx context.Context, status string, limit int) ([]*models.Report, error)
+
+	// HasOpenReport checks if a reporter IP already has an open report on a URL
+	HasOpenReport(ctx context.Context, urlID int64, reporterIP string) (bool, error)
+
+	// CountOpenReporters counts the distinct reporter IPs with open reports on a URL
+	CountOpenReporters(ctx context.Context, urlID int64) (int, error)
+
+	// ResolveReport sets the status of an open report
+	ResolveReport(ctx context.Context, id int64, status string) error
+
+	// ResolveOpenReports sets the status of every open report on a URL
+	ResolveOpenReports(ctx context.Context, urlID int64, status string) error
+}
+
+// APIKeyRepository defines the interface for API key storage operations
+type APIKeyRepository interface {
+	// CreateAPIKey stores a new API key
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+
+	// ListAPIKeys retrieves all API keys, including revoked ones
+	ListAPIKeys(ctx context.Context) ([]*models.APIKey, error)
+
+	// GetAPIKeyByHash retrieves an API key by the hash of its secret
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+
+	// TouchAPIKey records that an API key was just used
+	TouchAPIKey(ctx context.Context, id int64) error
+
+	// RevokeAPIKey revokes an API key
+	RevokeAPIKey(ctx context.Context, id int64) error
+}
+
+// TagRepository defines the interface for tag and folder storage operations
+type TagRepository interface {
+	// AddURLTags adds tags to a URL
+	AddURLTags(ctx context.Context, urlID int64, tags []string) error
+
+	// RemoveURLTag removes a tag from a URL
+	RemoveURLTag(ctx context.Context, urlID int64, tag string) error
+
+	// ListTags retrieves every tag in use with the number of links and visits
+	ListTags(ctx context.Context) ([]*models.TagStats, error)
+
+	// GetTagStats retrieves usage statistics of the links with a tag
+	GetTagStats(ctx context.Context, tag string) (*models.Stats, error)
+
+	// SetURLFolder moves a URL into a folder, or out of any folder if folder is empty
+	SetURLFolder(ctx context.Context, urlID int64, folder string) error
+
+	// ListFolders retrieves every folder in use with the number of links and visits
+	ListFolders(ctx context.Context) ([]*models.FolderStats, error)
+}
+
+// WebhookRepository defines the interface for webhook storage operations
+type WebhookRepository interface {
+	// CreateWebhook stores a new webhook
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+
+	// ListWebhooks retrieves all webhooks, including their secrets
+	ListWebhooks(ctx context.Context) ([]*models.Webhook, error)
+
+	// GetWebhook retrieves a webhook by ID, including its secret
+	GetWebhook(ctx context.Context, id int64) (*models.Webhook, error)
+
+	// DeleteWebhook deletes a webhook along with its deliveries
+	DeleteWebhook(ctx context.Context, id int64) error
+
+	// EnqueueDeliveries queues a payload for delivery to each of the webhooks
+	EnqueueDeliveries(ctx context.Context, webhookIDs []int64, event, payload string) error
+
+	// ListDueDeliveries retrieves up to limit pending deliveries due at now, oldest first
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error)
+
+	// ListDeliveries retrieves the newest deliveries matching a filter
+	ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]*models.WebhookDelivery, error)
+
+	// RecordDeliverySuccess marks a delivery as delivered
+	RecordDeliverySuccess(ctx context.Context, id int64, statusCode int) error
+
+	// RecordDeliveryFailure records a failed attempt, retried at nextAttempt unless dead
+	RecordDeliveryFailure(ctx context.Context, id int64, statusCode int, message string, nextAttempt time.Time, dead bool) error
+
+	// RetryDelivery queues a delivered or dead delivery to be sent again now
+	RetryDelivery(ctx context.Context, id int64) error
+
+	// PruneDeliveries deletes delivered and dead deliveries older than the specified age
+	PruneDeliveries(ctx context.Context, age time.Duration) (int64, error)
+}
@@ -3,11 +3,19 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	neturl "net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/metrics"
 	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // SQLiteRepository implements URLRepository interface using SQLite database
@@ -28,26 +36,109 @@ const (
 		CREATE INDEX IF NOT EXISTS idx_short_code ON urls(short_code);
 		CREATE INDEX IF NOT EXISTS idx_original_url ON urls(original_url);
 	`
-	insertURLSQL       = `INSERT INTO urls (original_url, short_code, created_at) VALUES (?, ?, datetime(?))`
-	getURLByCodeSQL    = `SELECT id, original_url, short_code, visits, created_at FROM urls WHERE short_code = ?`
-	getURLByOriginalSQL = `SELECT id, original_url, short_code, visits, created_at FROM urls WHERE original_url = ?`
-	incrementVisitsSQL = `UPDATE urls SET visits = visits + 1 WHERE short_code = ?`
-	getRecentURLsSQL   = `
-		SELECT id, original_url, short_code, visits, created_at
+	// urlColumns lists the columns read by scanURL, in order
+	urlColumns = `id, original_url, short_code, visits, created_at, password_hash, owner, interstitial, fallback_url, domain, disabled, disabled_reason, health_status, health_latency_ms, health_error, health_checked_at, last_visited_at, folder, title, description, notes, metadata_pending, card_title, card_description, card_image_url, no_expire`
+
+	insertURLSQL = `
+		INSERT INTO urls (original_url, short_code, visits, created_at, password_hash, owner, interstitial, fallback_url, domain, disabled, disabled_reason, destination_host, last_visited_at, folder, title, description, notes, metadata_pending, card_title, card_description, card_image_url, no_expire)
+		VALUES (?, ?, ?, datetime(?), ?, ?, ?, ?, ?, ?, ?, ?, datetime(?), ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	getURLByCodeSQL = `SELECT ` + urlColumns + ` FROM urls WHERE domain = ? AND short_code = ?`
+	// Only plain links are matched so that reusing one never inherits extra behavior
+	getURLByOriginalSQL = `
+		SELECT ` + urlColumns + `
+		FROM urls
+		WHERE original_url = ?
+			AND domain = ?
+			AND password_hash = ''
+			AND owner = ''
+			AND interstitial = 0
+			AND fallback_url = ''
+			AND disabled = 0
+			AND folder = ''
+			AND notes = ''
+			AND card_title = ''
+			AND card_description = ''
+			AND card_image_url = ''
+			AND NOT EXISTS (SELECT 1 FROM url_geo_targets WHERE url_id = urls.id)
+			AND NOT EXISTS (SELECT 1 FROM url_tags WHERE url_id = urls.id)
+	`
+	incrementVisitsSQL = `UPDATE urls SET visits = visits + 1, last_visited_at = datetime('now') WHERE id = ?`
+	getRecentURLsSQL   = `SELECT ` + urlColumns + ` FROM urls`
+	listEnabledURLsSQL = `
+		SELECT ` + urlColumns + `
+		FROM urls
+		WHERE disabled = 0 AND id > ?
+		ORDER BY id
+		LIMIT ?
+	`
+	listURLsSQL = `
+		SELECT ` + urlColumns + `
+		FROM urls
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?
+	`
+	listOldURLsSQL = `
+		SELECT ` + urlColumns + `
+		FROM urls
+		WHERE created_at < datetime(?) AND no_expire = 0
+		ORDER BY id
+		LIMIT ?
+	`
+	listPendingMetadataSQL = `
+		SELECT ` + urlColumns + `
 		FROM urls
-		ORDER BY created_at DESC
+		WHERE metadata_pending = 1 AND id > ?
+		ORDER BY id
 		LIMIT ?
 	`
-	deleteOldURLsSQL = `DELETE FROM urls WHERE created_at < datetime(?)`
-	checkCodeSQL     = `SELECT EXISTS(SELECT 1 FROM urls WHERE short_code = ?)`
-	getStatsCountSQL = `SELECT COUNT(*) FROM urls`
-	getStatsSumSQL   = `SELECT COALESCE(SUM(visits), 0) FROM urls`
+	updateURLDetailsSQL = `
+		UPDATE urls
+		SET title = ?, description = ?, notes = ?, card_title = ?, card_description = ?, card_image_url = ?
+		WHERE id = ?
+	`
+	// Fetched metadata never overwrites fields set by the user
+	recordMetadataSQL = `
+		UPDATE urls
+		SET title = CASE WHEN title = '' THEN ? ELSE title END,
+			description = CASE WHEN description = '' THEN ? ELSE description END,
+			metadata_pending = 0
+		WHERE id = ?
+	`
+	deleteURLSQL    = `DELETE FROM urls WHERE id = ?`
+	disableURLSQL   = `UPDATE urls SET disabled = 1, disabled_reason = ? WHERE id = ?`
+	enableURLSQL    = `UPDATE urls SET disabled = 0, disabled_reason = '' WHERE id = ?`
+	updateHealthSQL = `
+		UPDATE urls
+		SET health_status = ?, health_latency_ms = ?, health_error = ?, health_checked_at = datetime(?)
+		WHERE id = ?
+	`
+	deleteOldURLsSQL  = `DELETE FROM urls WHERE created_at < datetime(?) AND no_expire = 0`
+	countOldURLsSQL   = `SELECT COUNT(*) FROM urls WHERE created_at < datetime(?) AND no_expire = 0`
+	checkCodeSQL      = `SELECT EXISTS(SELECT 1 FROM urls WHERE domain = ? AND short_code = ?)`
+	pingSQL           = `SELECT COUNT(*) FROM (SELECT 1 FROM urls LIMIT 1)`
+	getStatsCountSQL  = `SELECT COUNT(*) FROM urls`
+	getStatsSumSQL    = `SELECT COALESCE(SUM(visits), 0) FROM urls`
 	getStatsLatestSQL = `SELECT MAX(datetime(created_at)) FROM urls`
+
+	insertGeoTargetSQL    = `INSERT INTO url_geo_targets (url_id, country, target_url) VALUES (?, ?, ?)`
+	getGeoTargetsSQL      = `SELECT country, target_url FROM url_geo_targets WHERE url_id = ?`
+	recordCountryVisitSQL = `
+		INSERT INTO url_country_visits (url_id, country, visits) VALUES (?, ?, 1)
+		ON CONFLICT(url_id, country) DO UPDATE SET visits = visits + 1
+	`
+	getCountryVisitsSQL = `
+		SELECT country, visits
+		FROM url_country_visits
+		WHERE url_id = ?
+		ORDER BY visits DESC, country
+	`
 )
 
 // NewSQLiteRepository creates a new SQLite repository
 func NewSQLiteRepository(dbPath string) (*SQLiteRepository, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open("sqlite3", withForeignKeys(dbPath))
 	if err != nil {
 		return nil, errors.NewDatabaseError(err)
 	}
@@ -60,7 +151,7 @@ func NewSQLiteRepository(dbPath string) (*SQLiteRepository, error) {
 	// Verify connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	
+
 	if err := db.PingContext(ctx); err != nil {
 		return nil, errors.NewDatabaseError(err)
 	}
@@ -70,11 +161,29 @@ func NewSQLiteRepository(dbPath string) (*SQLiteRepository, error) {
 		return nil, errors.NewDatabaseError(err)
 	}
 
-	return &SQLiteRepository{db: db}, nil
+	repo := &SQLiteRepository{db: db}
+	if err := repo.migrate(ctx); err != nil {
+		return nil, err
+	}
+	if err := repo.backfillDestinationHosts(ctx); err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// withForeignKeys enables foreign key enforcement on every pooled connection
+func withForeignKeys(dbPath string) string {
+	if strings.Contains(dbPath, "?") {
+		return dbPath + "&_foreign_keys=on"
+	}
+	return dbPath + "?_foreign_keys=on"
 }
 
 // Create stores a new URL in the database
 func (r *SQLiteRepository) Create(ctx context.Context, url *models.URL) error {
+	ctx, done := observe(ctx, "Create")
+	defer done()
 	if url == nil {
 		return errors.NewValidationError("url cannot be nil")
 	}
@@ -83,13 +192,39 @@ func (r *SQLiteRepository) Create(ctx context.Context, url *models.URL) error {
 	if url.CreatedAt.IsZero() {
 		url.CreatedAt = time.Now()
 	}
+	card := cardOf(url)
 
-	result, err := r.db.ExecContext(
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
 		ctx,
 		insertURLSQL,
 		url.OriginalURL,
 		url.ShortCode,
+		url.Visits,
 		url.CreatedAt.UTC().Format("2006-01-02 15:04:05"),
+		url.PasswordHash,
+		url.Owner,
+		url.Interstitial,
+		url.FallbackURL,
+		url.Domain,
+		url.Disabled,
+		url.DisabledReason,
+		destinationHost(url.OriginalURL),
+		formatOptionalTime(url.LastVisitedAt),
+		url.Folder,
+		url.Title,
+		url.Description,
+		url.Notes,
+		url.MetadataPending,
+		card.Title,
+		card.Description,
+		card.ImageURL,
+		url.NoExpire,
 	)
 	if err != nil {
 		return errors.NewDatabaseError(err)
@@ -100,71 +235,160 @@ func (r *SQLiteRepository) Create(ctx context.Context, url *models.URL) error {
 		return errors.NewDatabaseError(err)
 	}
 
+	for country, target := range url.GeoTargets {
+		if _, err := tx.ExecContext(ctx, insertGeoTargetSQL, id, country, target); err != nil {
+			return errors.NewDatabaseError(err)
+		}
+	}
+	if err := insertURLTags(ctx, tx, id, url.Tags); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
 	url.ID = id
 	return nil
 }
 
-// GetByOriginalURL retrieves a URL by its original URL
-func (r *SQLiteRepository) GetByOriginalURL(ctx context.Context, originalURL string) (*models.URL, error) {
+// GetByOriginalURL retrieves a plain URL on a domain by its original URL
+func (r *SQLiteRepository) GetByOriginalURL(ctx context.Context, domain, originalURL string) (*models.URL, error) {
+	ctx, done := observe(ctx, "GetByOriginalURL")
+	defer done()
 	if originalURL == "" {
 		return nil, errors.NewValidationError("original URL cannot be empty")
 	}
 
-	url := &models.URL{}
-	err := r.db.QueryRowContext(
-		ctx,
-		getURLByOriginalSQL,
-		originalURL,
-	).Scan(
-		&url.ID,
-		&url.OriginalURL,
-		&url.ShortCode,
-		&url.Visits,
-		&url.CreatedAt,
-	)
-
+	url, err := scanURL(r.db.QueryRowContext(ctx, getURLByOriginalSQL, originalURL, domain))
 	if err == sql.ErrNoRows {
 		return nil, errors.NewNotFoundError("URL not found")
 	}
 	if err != nil {
 		return nil, errors.NewDatabaseError(err)
 	}
+	if err := r.loadTags(ctx, url); err != nil {
+		return nil, err
+	}
 
 	return url, nil
 }
 
-// GetByCode retrieves a URL by its short code
-func (r *SQLiteRepository) GetByCode(ctx context.Context, code string) (*models.URL, error) {
+// GetByCode retrieves a URL by its domain and short code
+func (r *SQLiteRepository) GetByCode(ctx context.Context, domain, code string) (*models.URL, error) {
+	ctx, done := observe(ctx, "GetByCode")
+	defer done()
 	if code == "" {
 		return nil, errors.NewValidationError("code cannot be empty")
 	}
 
+	url, err := scanURL(r.db.QueryRowContext(ctx, getURLByCodeSQL, domain, code))
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFoundError("URL not found")
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	if err := r.loadGeoTargets(ctx, url); err != nil {
+		return nil, err
+	}
+	if err := r.loadTags(ctx, url); err != nil {
+		return nil, err
+	}
+
+	return url, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanURL reads a URL selected with urlColumns
+func scanURL(row rowScanner) (*models.URL, error) {
 	url := &models.URL{}
-	err := r.db.QueryRowContext(ctx, getURLByCodeSQL, code).Scan(
+	var health models.LinkHealth
+	var card models.SocialCard
+	var checkedAt, lastVisitedAt sql.NullTime
+	err := row.Scan(
 		&url.ID,
 		&url.OriginalURL,
 		&url.ShortCode,
 		&url.Visits,
 		&url.CreatedAt,
+		&url.PasswordHash,
+		&url.Owner,
+		&url.Interstitial,
+		&url.FallbackURL,
+		&url.Domain,
+		&url.Disabled,
+		&url.DisabledReason,
+		&health.StatusCode,
+		&health.LatencyMS,
+		&health.Error,
+		&checkedAt,
+		&lastVisitedAt,
+		&url.Folder,
+		&url.Title,
+		&url.Description,
+		&url.Notes,
+		&url.MetadataPending,
+		&card.Title,
+		&card.Description,
+		&card.ImageURL,
+		&url.NoExpire,
 	)
+	if err != nil {
+		return nil, err
+	}
 
-	if err == sql.ErrNoRows {
-		return nil, errors.NewNotFoundError("URL not found")
+	if checkedAt.Valid {
+		health.CheckedAt = checkedAt.Time
+		url.Health = &health
 	}
-	if err != nil {
-		return nil, errors.NewDatabaseError(err)
+	if lastVisitedAt.Valid {
+		url.LastVisitedAt = &lastVisitedAt.Time
+	}
+	if card != (models.SocialCard{}) {
+		url.Card = &card
 	}
 
+	url.Protected = url.PasswordHash != ""
 	return url, nil
 }
 
-// IncrementVisits increments the visit counter for a URL
-func (r *SQLiteRepository) IncrementVisits(ctx context.Context, code string) error {
-	if code == "" {
-		return errors.NewValidationError("code cannot be empty")
+// loadGeoTargets populates the per-country destinations of a URL
+func (r *SQLiteRepository) loadGeoTargets(ctx context.Context, url *models.URL) error {
+	rows, err := r.db.QueryContext(ctx, getGeoTargetsSQL, url.ID)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var country, target string
+		if err := rows.Scan(&country, &target); err != nil {
+			return errors.NewDatabaseError(err)
+		}
+		if url.GeoTargets == nil {
+			url.GeoTargets = make(map[string]string)
+		}
+		url.GeoTargets[country] = target
 	}
 
-	result, err := r.db.ExecContext(ctx, incrementVisitsSQL, code)
+	if err := rows.Err(); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	return nil
+}
+
+// IncrementVisits increments the visit counter for a URL
+func (r *SQLiteRepository) IncrementVisits(ctx context.Context, urlID int64) error {
+	ctx, done := observe(ctx, "IncrementVisits")
+	defer done()
+	result, err := r.db.ExecContext(ctx, incrementVisitsSQL, urlID)
 	if err != nil {
 		return errors.NewDatabaseError(err)
 	}
@@ -181,18 +405,183 @@ func (r *SQLiteRepository) IncrementVisits(ctx context.Context, code string) err
 	return nil
 }
 
+// RecordCountryVisit increments the per-country visit counter for a URL
+func (r *SQLiteRepository) RecordCountryVisit(ctx context.Context, urlID int64, country string) error {
+	ctx, done := observe(ctx, "RecordCountryVisit")
+	defer done()
+	if country == "" {
+		return errors.NewValidationError("country cannot be empty")
+	}
+
+	if _, err := r.db.ExecContext(ctx, recordCountryVisitSQL, urlID, country); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	return nil
+}
+
+// GetCountryVisits retrieves the per-country visit breakdown for a URL
+func (r *SQLiteRepository) GetCountryVisits(ctx context.Context, urlID int64) ([]*models.CountryVisits, error) {
+	ctx, done := observe(ctx, "GetCountryVisits")
+	defer done()
+	rows, err := r.db.QueryContext(ctx, getCountryVisitsSQL, urlID)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	defer rows.Close()
+
+	visits := []*models.CountryVisits{}
+	for rows.Next() {
+		v := &models.CountryVisits{}
+		if err := rows.Scan(&v.Country, &v.Visits); err != nil {
+			return nil, errors.NewDatabaseError(err)
+		}
+		visits = append(visits, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	return visits, nil
+}
+
 // Close closes the repository connection
 func (r *SQLiteRepository) Close() error {
 	return r.db.Close()
 }
 
-// GetRecentURLs retrieves recent URLs with pagination
-func (r *SQLiteRepository) GetRecentURLs(ctx context.Context, limit int) ([]*models.URL, error) {
+// Ping checks that the database is reachable and its tables can be read
+func (r *SQLiteRepository) Ping(ctx context.Context) error {
+	ctx, done := observe(ctx, "Ping")
+	defer done()
+
+	if err := r.db.PingContext(ctx); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	var n int
+	if err := r.db.QueryRowContext(ctx, pingSQL).Scan(&n); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// healthConditions maps health filters to SQL conditions
+var healthConditions = map[string]string{
+	models.HealthBroken:    `health_checked_at IS NOT NULL AND (health_status = 0 OR health_status >= 400)`,
+	models.HealthHealthy:   `health_checked_at IS NOT NULL AND health_status > 0 AND health_status < 400`,
+	models.HealthUnchecked: `health_checked_at IS NULL`,
+}
+
+// sortColumns maps sort orders to the SQL expressions they sort by. Each has
+// an index together with id.
+var sortColumns = map[string]string{
+	SortCreated:     `created_at`,
+	SortVisits:      `visits`,
+	SortLastVisited: `COALESCE(last_visited_at, '')`,
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// GetRecentURLs retrieves URLs matching a filter in its sort order, newest
+// first by default
+func (r *SQLiteRepository) GetRecentURLs(ctx context.Context, filter URLFilter) ([]*models.URL, error) {
+	ctx, done := observe(ctx, "GetRecentURLs")
+	defer done()
+	limit := filter.Limit
 	if limit <= 0 {
 		limit = 10 // Default limit
 	}
 
-	rows, err := r.db.QueryContext(ctx, getRecentURLsSQL, limit)
+	if filter.Sort == "" {
+		filter.Sort = SortCreated
+	}
+	sortColumn, ok := sortColumns[filter.Sort]
+	if !ok {
+		return nil, errors.NewValidationError("Invalid sort order")
+	}
+
+	var conditions []string
+	var args []interface{}
+	if filter.Health != "" {
+		condition, ok := healthConditions[filter.Health]
+		if !ok {
+			return nil, errors.NewValidationError("Invalid health filter")
+		}
+		conditions = append(conditions, condition)
+	}
+	switch filter.Status {
+	case "":
+	case StatusActive:
+		conditions = append(conditions, `disabled = 0`)
+	case StatusDisabled:
+		conditions = append(conditions, `disabled = 1`)
+	default:
+		return nil, errors.NewValidationError("Invalid status filter")
+	}
+	if filter.Owner != "" {
+		conditions = append(conditions, `owner = ?`)
+		args = append(args, filter.Owner)
+	}
+	if filter.Tag != "" {
+		conditions = append(conditions, `id IN (SELECT url_id FROM url_tags WHERE tag = ?)`)
+		args = append(args, filter.Tag)
+	}
+	if filter.Folder != "" {
+		conditions = append(conditions, `folder = ?`)
+		args = append(args, filter.Folder)
+	}
+
+	// Hidden destinations must not be revealed by what they match
+	unprotected := ""
+	if !filter.IncludeProtected {
+		unprotected = `password_hash = '' AND `
+	}
+	if filter.Destination != "" {
+		host := strings.ToLower(strings.TrimSuffix(filter.Destination, "."))
+		conditions = append(conditions, unprotected+`(destination_host = ? OR destination_host LIKE ? ESCAPE '\')`)
+		args = append(args, host, "%."+likeEscaper.Replace(host))
+	}
+	if filter.Search != "" {
+		pattern := "%" + likeEscaper.Replace(filter.Search) + "%"
+		conditions = append(conditions, `(short_code LIKE ? ESCAPE '\' OR title LIKE ? ESCAPE '\' OR (`+unprotected+`original_url LIKE ? ESCAPE '\'))`)
+		args = append(args, pattern, pattern, pattern)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		conditions = append(conditions, `created_at >= ?`)
+		args = append(args, filter.CreatedAfter.UTC().Format("2006-01-02 15:04:05"))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conditions = append(conditions, `created_at < ?`)
+		args = append(args, filter.CreatedBefore.UTC().Format("2006-01-02 15:04:05"))
+	}
+
+	direction, comparison := "DESC", "<"
+	if filter.Ascending {
+		direction, comparison = "ASC", ">"
+	}
+	if filter.After != nil {
+		var value interface{} = filter.After.Value
+		if filter.Sort == SortVisits {
+			visits, err := strconv.ParseInt(filter.After.Value, 10, 64)
+			if err != nil {
+				return nil, errors.NewValidationError("Invalid cursor")
+			}
+			value = visits
+		}
+		conditions = append(conditions, fmt.Sprintf(`(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))`, sortColumn, comparison))
+		args = append(args, value, value, filter.After.ID)
+	}
+
+	query := getRecentURLsSQL
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	query += fmt.Sprintf(` ORDER BY %[1]s %[2]s, id %[2]s LIMIT ?`, sortColumn, direction)
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, errors.NewDatabaseError(err)
 	}
@@ -200,14 +589,7 @@ func (r *SQLiteRepository) GetRecentURLs(ctx context.Context, limit int) ([]*mod
 
 	var urls []*models.URL
 	for rows.Next() {
-		url := &models.URL{}
-		err := rows.Scan(
-			&url.ID,
-			&url.OriginalURL,
-			&url.ShortCode,
-			&url.Visits,
-			&url.CreatedAt,
-		)
+		url, err := scanURL(rows)
 		if err != nil {
 			return nil, errors.NewDatabaseError(err)
 		}
@@ -217,12 +599,52 @@ func (r *SQLiteRepository) GetRecentURLs(ctx context.Context, limit int) ([]*mod
 	if err := rows.Err(); err != nil {
 		return nil, errors.NewDatabaseError(err)
 	}
+	rows.Close()
+
+	if err := r.loadTags(ctx, urls...); err != nil {
+		return nil, err
+	}
 
 	return urls, nil
 }
 
+// CursorFor returns the position of url in the sort order of a listing
+func CursorFor(sort string, url *models.URL) *URLCursor {
+	cursor := &URLCursor{ID: url.ID}
+	switch sort {
+	case SortVisits:
+		cursor.Value = strconv.Itoa(url.Visits)
+	case SortLastVisited:
+		cursor.Value = formatOptionalTime(url.LastVisitedAt)
+	default:
+		cursor.Value = url.CreatedAt.UTC().Format("2006-01-02 15:04:05")
+	}
+	return cursor
+}
+
+// formatOptionalTime formats a time as stored, or returns an empty string
+// for nil
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// destinationHost returns the lowercase host of a destination, or an empty
+// string for destinations without one such as mailto: links
+func destinationHost(destination string) string {
+	u, err := neturl.Parse(destination)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
+
 // GetStats retrieves usage statistics
 func (r *SQLiteRepository) GetStats(ctx context.Context) (*models.Stats, error) {
+	ctx, done := observe(ctx, "GetStats")
+	defer done()
 	stats := &models.Stats{}
 
 	// Get total URLs
@@ -252,8 +674,27 @@ func (r *SQLiteRepository) GetStats(ctx context.Context) (*models.Stats, error)
 	return stats, nil
 }
 
-// DeleteOldURLs deletes URLs older than the specified age
+// CountOldURLs counts the URLs that DeleteOldURLs would delete
+func (r *SQLiteRepository) CountOldURLs(ctx context.Context, age time.Duration) (int64, error) {
+	ctx, done := observe(ctx, "CountOldURLs")
+	defer done()
+	if age <= 0 {
+		return 0, errors.NewValidationError("age must be positive")
+	}
+
+	var count int64
+	cutoff := time.Now().Add(-age).UTC().Format("2006-01-02 15:04:05")
+	if err := r.db.QueryRowContext(ctx, countOldURLsSQL, cutoff).Scan(&count); err != nil {
+		return 0, errors.NewDatabaseError(err)
+	}
+	return count, nil
+}
+
+// DeleteOldURLs deletes URLs older than the specified age. URLs exempt from
+// expiry are kept.
 func (r *SQLiteRepository) DeleteOldURLs(ctx context.Context, age time.Duration) (int64, error) {
+	ctx, done := observe(ctx, "DeleteOldURLs")
+	defer done()
 	if age <= 0 {
 		return 0, errors.NewValidationError("age must be positive")
 	}
@@ -272,17 +713,203 @@ func (r *SQLiteRepository) DeleteOldURLs(ctx context.Context, age time.Duration)
 	return rowsDeleted, nil
 }
 
-// CodeExists checks if a short code already exists
-func (r *SQLiteRepository) CodeExists(ctx context.Context, code string) (bool, error) {
+// ListEnabledURLs retrieves up to limit enabled URLs with IDs above afterID,
+// including their geo targets, in ID order
+func (r *SQLiteRepository) ListEnabledURLs(ctx context.Context, afterID int64, limit int) ([]*models.URL, error) {
+	ctx, done := observe(ctx, "ListEnabledURLs")
+	defer done()
+	return r.listURLs(ctx, listEnabledURLsSQL, afterID, limit)
+}
+
+// ListURLs retrieves up to limit URLs with IDs above afterID, including
+// disabled ones and their geo targets, in ID order
+func (r *SQLiteRepository) ListURLs(ctx context.Context, afterID int64, limit int) ([]*models.URL, error) {
+	ctx, done := observe(ctx, "ListURLs")
+	defer done()
+	return r.listURLs(ctx, listURLsSQL, afterID, limit)
+}
+
+// ListOldURLs retrieves up to limit URLs that DeleteOldURLs would delete, in
+// ID order
+func (r *SQLiteRepository) ListOldURLs(ctx context.Context, age time.Duration, limit int) ([]*models.URL, error) {
+	ctx, done := observe(ctx, "ListOldURLs")
+	defer done()
+	if age <= 0 {
+		return nil, errors.NewValidationError("age must be positive")
+	}
+
+	cutoff := time.Now().Add(-age).UTC().Format("2006-01-02 15:04:05")
+	return r.listURLs(ctx, listOldURLsSQL, cutoff, limit)
+}
+
+// ListPendingMetadata retrieves up to limit URLs with IDs above afterID whose
+// page metadata has not been fetched yet, in ID order
+func (r *SQLiteRepository) ListPendingMetadata(ctx context.Context, afterID int64, limit int) ([]*models.URL, error) {
+	ctx, done := observe(ctx, "ListPendingMetadata")
+	defer done()
+	return r.listURLs(ctx, listPendingMetadataSQL, afterID, limit)
+}
+
+// UpdateURLDetails stores the title, description, notes and social card of a URL
+func (r *SQLiteRepository) UpdateURLDetails(ctx context.Context, url *models.URL) error {
+	ctx, done := observe(ctx, "UpdateURLDetails")
+	defer done()
+	card := cardOf(url)
+	result, err := r.db.ExecContext(
+		ctx,
+		updateURLDetailsSQL,
+		url.Title,
+		url.Description,
+		url.Notes,
+		card.Title,
+		card.Description,
+		card.ImageURL,
+		url.ID,
+	)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	if affected == 0 {
+		return errors.NewNotFoundError("URL not found")
+	}
+	return nil
+}
+
+// RecordURLMetadata fills in the empty title and description of a URL with
+// fetched page metadata and clears its pending flag
+func (r *SQLiteRepository) RecordURLMetadata(ctx context.Context, urlID int64, title, description string) error {
+	ctx, done := observe(ctx, "RecordURLMetadata")
+	defer done()
+	if _, err := r.db.ExecContext(ctx, recordMetadataSQL, title, description, urlID); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// cardOf returns the social card of a URL, empty when it has none
+func cardOf(url *models.URL) models.SocialCard {
+	if url.Card == nil {
+		return models.SocialCard{}
+	}
+	return *url.Card
+}
+
+// listURLs runs a paged URL query and loads the geo targets of the results
+func (r *SQLiteRepository) listURLs(ctx context.Context, query string, args ...any) ([]*models.URL, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	defer rows.Close()
+
+	var urls []*models.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, errors.NewDatabaseError(err)
+		}
+		urls = append(urls, url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	rows.Close()
+
+	for _, url := range urls {
+		if err := r.loadGeoTargets(ctx, url); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.loadTags(ctx, urls...); err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// DeleteURL deletes a URL along with its geo targets, visits and reports
+func (r *SQLiteRepository) DeleteURL(ctx context.Context, urlID int64) error {
+	ctx, done := observe(ctx, "DeleteURL")
+	defer done()
+	result, err := r.db.ExecContext(ctx, deleteURLSQL, urlID)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "URL not found")
+}
+
+// DisableURL marks a URL as disabled with a reason
+func (r *SQLiteRepository) DisableURL(ctx context.Context, urlID int64, reason string) error {
+	ctx, done := observe(ctx, "DisableURL")
+	defer done()
+	result, err := r.db.ExecContext(ctx, disableURLSQL, reason, urlID)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "URL not found")
+}
+
+// EnableURL clears the disabled flag of a URL
+func (r *SQLiteRepository) EnableURL(ctx context.Context, urlID int64) error {
+	ctx, done := observe(ctx, "EnableURL")
+	defer done()
+	result, err := r.db.ExecContext(ctx, enableURLSQL, urlID)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "URL not found")
+}
+
+// UpdateURLHealth records the latest destination check of a URL
+func (r *SQLiteRepository) UpdateURLHealth(ctx context.Context, urlID int64, health *models.LinkHealth) error {
+	ctx, done := observe(ctx, "UpdateURLHealth")
+	defer done()
+	_, err := r.db.ExecContext(
+		ctx,
+		updateHealthSQL,
+		health.StatusCode,
+		health.LatencyMS,
+		health.Error,
+		health.CheckedAt.UTC().Format("2006-01-02 15:04:05"),
+		urlID,
+	)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// CodeExists checks if a short code already exists on a domain
+func (r *SQLiteRepository) CodeExists(ctx context.Context, domain, code string) (bool, error) {
+	ctx, done := observe(ctx, "CodeExists")
+	defer done()
 	if code == "" {
 		return false, errors.NewValidationError("code cannot be empty")
 	}
 
 	var exists bool
-	err := r.db.QueryRowContext(ctx, checkCodeSQL, code).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, checkCodeSQL, domain, code).Scan(&exists)
 	if err != nil {
 		return false, errors.NewDatabaseError(err)
 	}
-	
+
 	return exists, nil
 }
+
+// observe starts a span for a repository method. The returned function ends
+// the span and records the query duration.
+func observe(ctx context.Context, method string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "SQLiteRepository."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemSqlite),
+	)
+	return ctx, func() {
+		span.End()
+		metrics.QueryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	}
+}
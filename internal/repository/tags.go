@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+)
+
+const (
+	insertURLTagSQL = `INSERT OR IGNORE INTO url_tags (url_id, tag) VALUES (?, ?)`
+	deleteURLTagSQL = `DELETE FROM url_tags WHERE url_id = ? AND tag = ?`
+	listTagsSQL     = `
+		SELECT t.tag, COUNT(*), COALESCE(SUM(u.visits), 0)
+		FROM url_tags t
+		JOIN urls u ON u.id = t.url_id
+		GROUP BY t.tag
+		ORDER BY t.tag
+	`
+	setURLFolderSQL = `UPDATE urls SET folder = ? WHERE id = ?`
+	listFoldersSQL  = `
+		SELECT folder, COUNT(*), COALESCE(SUM(visits), 0)
+		FROM urls
+		WHERE folder != ''
+		GROUP BY folder
+		ORDER BY folder
+	`
+	getTagStatsSQL = `
+		SELECT COUNT(*), COALESCE(SUM(u.visits), 0), MAX(datetime(u.created_at))
+		FROM url_tags t
+		JOIN urls u ON u.id = t.url_id
+		WHERE t.tag = ?
+	`
+)
+
+// AddURLTags adds tags to a URL. Tags it already has are kept once.
+func (r *SQLiteRepository) AddURLTags(ctx context.Context, urlID int64, tags []string) error {
+	ctx, done := observe(ctx, "AddURLTags")
+	defer done()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	defer tx.Rollback()
+
+	if err := insertURLTags(ctx, tx, urlID, tags); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// insertURLTags adds tags to a URL within a transaction
+func insertURLTags(ctx context.Context, tx *sql.Tx, urlID int64, tags []string) error {
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, insertURLTagSQL, urlID, tag); err != nil {
+			return errors.NewDatabaseError(err)
+		}
+	}
+	return nil
+}
+
+// RemoveURLTag removes a tag from a URL
+func (r *SQLiteRepository) RemoveURLTag(ctx context.Context, urlID int64, tag string) error {
+	ctx, done := observe(ctx, "RemoveURLTag")
+	defer done()
+	result, err := r.db.ExecContext(ctx, deleteURLTagSQL, urlID, tag)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "Tag not found on this link")
+}
+
+// ListTags retrieves every tag in use with the number of links and visits
+func (r *SQLiteRepository) ListTags(ctx context.Context) ([]*models.TagStats, error) {
+	ctx, done := observe(ctx, "ListTags")
+	defer done()
+	rows, err := r.db.QueryContext(ctx, listTagsSQL)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	defer rows.Close()
+
+	tags := []*models.TagStats{}
+	for rows.Next() {
+		tag := &models.TagStats{}
+		if err := rows.Scan(&tag.Tag, &tag.Links, &tag.Visits); err != nil {
+			return nil, errors.NewDatabaseError(err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	return tags, nil
+}
+
+// GetTagStats retrieves usage statistics of the links with a tag
+func (r *SQLiteRepository) GetTagStats(ctx context.Context, tag string) (*models.Stats, error) {
+	ctx, done := observe(ctx, "GetTagStats")
+	defer done()
+
+	stats := &models.Stats{}
+	var lastCreated sql.NullString
+	if err := r.db.QueryRowContext(ctx, getTagStatsSQL, tag).Scan(&stats.TotalURLs, &stats.TotalVisits, &lastCreated); err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	stats.LastCreated = lastCreated.String
+	return stats, nil
+}
+
+// SetURLFolder moves a URL into a folder, or out of any folder if folder is empty
+func (r *SQLiteRepository) SetURLFolder(ctx context.Context, urlID int64, folder string) error {
+	ctx, done := observe(ctx, "SetURLFolder")
+	defer done()
+	result, err := r.db.ExecContext(ctx, setURLFolderSQL, folder, urlID)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "URL not found")
+}
+
+// ListFolders retrieves every folder in use with the number of links and visits
+func (r *SQLiteRepository) ListFolders(ctx context.Context) ([]*models.FolderStats, error) {
+	ctx, done := observe(ctx, "ListFolders")
+	defer done()
+	rows, err := r.db.QueryContext(ctx, listFoldersSQL)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	defer rows.Close()
+
+	folders := []*models.FolderStats{}
+	for rows.Next() {
+		folder := &models.FolderStats{}
+		if err := rows.Scan(&folder.Folder, &folder.Links, &folder.Visits); err != nil {
+			return nil, errors.NewDatabaseError(err)
+		}
+		folders = append(folders, folder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	return folders, nil
+}
+
+// loadTags populates the tags of URLs with a single query
+func (r *SQLiteRepository) loadTags(ctx context.Context, urls ...*models.URL) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*models.URL, len(urls))
+	args := make([]interface{}, len(urls))
+	for i, url := range urls {
+		byID[url.ID] = url
+		args[i] = url.ID
+	}
+
+	query := `SELECT url_id, tag FROM url_tags WHERE url_id IN (?` + strings.Repeat(`, ?`, len(urls)-1) + `) ORDER BY tag`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var urlID int64
+		var tag string
+		if err := rows.Scan(&urlID, &tag); err != nil {
+			return errors.NewDatabaseError(err)
+		}
+		if url := byID[urlID]; url != nil {
+			url.Tags = append(url.Tags, tag)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	return nil
+}
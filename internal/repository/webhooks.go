@@ -0,0 +1,328 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+)
+
+const (
+	// webhookColumns lists the columns read by scanWebhook, in order
+	webhookColumns = `id, url, events, secret, created_at`
+
+	// deliveryColumns lists the columns read by scanDelivery, in order
+	deliveryColumns = `id, webhook_id, event, payload, status, attempts, next_attempt_at,
+		last_status_code, last_error, created_at, delivered_at`
+
+	insertWebhookSQL = `INSERT INTO webhooks (url, events, secret, created_at) VALUES (?, ?, ?, datetime(?))`
+	listWebhooksSQL  = `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY id`
+	getWebhookSQL    = `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = ?`
+	deleteWebhookSQL = `DELETE FROM webhooks WHERE id = ?`
+
+	insertDeliverySQL    = `INSERT INTO webhook_deliveries (webhook_id, event, payload) VALUES (?, ?, ?)`
+	listDueDeliveriesSQL = `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt_at <= datetime(?)
+		ORDER BY next_attempt_at, id
+		LIMIT ?
+	`
+	listDeliveriesSQL  = `SELECT ` + deliveryColumns + ` FROM webhook_deliveries`
+	recordDeliveredSQL = `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', attempts = attempts + 1, last_status_code = ?, last_error = '',
+			delivered_at = datetime('now')
+		WHERE id = ?
+	`
+	recordFailedDeliverySQL = `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = attempts + 1, last_status_code = ?, last_error = ?,
+			next_attempt_at = datetime(?)
+		WHERE id = ?
+	`
+	retryDeliverySQL = `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = datetime('now')
+		WHERE id = ? AND status != 'pending'
+	`
+	pruneDeliveriesSQL = `DELETE FROM webhook_deliveries WHERE status != 'pending' AND created_at < datetime(?)`
+)
+
+// DeliveryFilter narrows a listing of webhook deliveries
+type DeliveryFilter struct {
+	WebhookID int64  // 0 for every webhook
+	Status    string // models.DeliveryPending, DeliveryDelivered or DeliveryDead; empty for all
+	Limit     int
+}
+
+// CreateWebhook stores a new webhook
+func (r *SQLiteRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	ctx, done := observe(ctx, "CreateWebhook")
+	defer done()
+	if webhook == nil {
+		return errors.NewValidationError("webhook cannot be nil")
+	}
+
+	if webhook.CreatedAt.IsZero() {
+		webhook.CreatedAt = time.Now()
+	}
+
+	result, err := r.db.ExecContext(
+		ctx,
+		insertWebhookSQL,
+		webhook.URL,
+		strings.Join(webhook.Events, ","),
+		webhook.Secret,
+		webhook.CreatedAt.UTC().Format("2006-01-02 15:04:05"),
+	)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	webhook.ID = id
+	return nil
+}
+
+// ListWebhooks retrieves all webhooks, including their secrets
+func (r *SQLiteRepository) ListWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	ctx, done := observe(ctx, "ListWebhooks")
+	defer done()
+	rows, err := r.db.QueryContext(ctx, listWebhooksSQL)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	defer rows.Close()
+
+	webhooks := []*models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, errors.NewDatabaseError(err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhook retrieves a webhook by ID, including its secret
+func (r *SQLiteRepository) GetWebhook(ctx context.Context, id int64) (*models.Webhook, error) {
+	ctx, done := observe(ctx, "GetWebhook")
+	defer done()
+	webhook, err := scanWebhook(r.db.QueryRowContext(ctx, getWebhookSQL, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFoundError("Webhook not found")
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook deletes a webhook along with its deliveries
+func (r *SQLiteRepository) DeleteWebhook(ctx context.Context, id int64) error {
+	ctx, done := observe(ctx, "DeleteWebhook")
+	defer done()
+	result, err := r.db.ExecContext(ctx, deleteWebhookSQL, id)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "Webhook not found")
+}
+
+// EnqueueDeliveries queues a payload for delivery to each of the webhooks
+func (r *SQLiteRepository) EnqueueDeliveries(ctx context.Context, webhookIDs []int64, event, payload string) error {
+	ctx, done := observe(ctx, "EnqueueDeliveries")
+	defer done()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range webhookIDs {
+		if _, err := tx.ExecContext(ctx, insertDeliverySQL, id, event, payload); err != nil {
+			return errors.NewDatabaseError(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// ListDueDeliveries retrieves up to limit pending deliveries whose next
+// attempt is due at now, oldest first
+func (r *SQLiteRepository) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	ctx, done := observe(ctx, "ListDueDeliveries")
+	defer done()
+	return r.queryDeliveries(ctx, listDueDeliveriesSQL, now.UTC().Format("2006-01-02 15:04:05"), limit)
+}
+
+// ListDeliveries retrieves the newest deliveries matching a filter
+func (r *SQLiteRepository) ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]*models.WebhookDelivery, error) {
+	ctx, done := observe(ctx, "ListDeliveries")
+	defer done()
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+
+	query := listDeliveriesSQL
+	var conditions []string
+	args := []any{}
+	if filter.WebhookID != 0 {
+		conditions = append(conditions, "webhook_id = ?")
+		args = append(args, filter.WebhookID)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, filter.Limit)
+
+	return r.queryDeliveries(ctx, query, args...)
+}
+
+// RecordDeliverySuccess marks a delivery as delivered
+func (r *SQLiteRepository) RecordDeliverySuccess(ctx context.Context, id int64, statusCode int) error {
+	ctx, done := observe(ctx, "RecordDeliverySuccess")
+	defer done()
+	result, err := r.db.ExecContext(ctx, recordDeliveredSQL, statusCode, id)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "Delivery not found")
+}
+
+// RecordDeliveryFailure records a failed attempt of a delivery. The delivery
+// is retried at nextAttempt, or marked dead if dead is set.
+func (r *SQLiteRepository) RecordDeliveryFailure(ctx context.Context, id int64, statusCode int, message string, nextAttempt time.Time, dead bool) error {
+	ctx, done := observe(ctx, "RecordDeliveryFailure")
+	defer done()
+	status := models.DeliveryPending
+	if dead {
+		status = models.DeliveryDead
+	}
+	result, err := r.db.ExecContext(
+		ctx,
+		recordFailedDeliverySQL,
+		status,
+		statusCode,
+		message,
+		nextAttempt.UTC().Format("2006-01-02 15:04:05"),
+		id,
+	)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "Delivery not found")
+}
+
+// RetryDelivery queues a delivered or dead delivery to be sent again now
+func (r *SQLiteRepository) RetryDelivery(ctx context.Context, id int64) error {
+	ctx, done := observe(ctx, "RetryDelivery")
+	defer done()
+	result, err := r.db.ExecContext(ctx, retryDeliverySQL, id)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return requireRowsAffected(result, "Delivery not found or already pending")
+}
+
+// PruneDeliveries deletes delivered and dead deliveries older than the
+// specified age
+func (r *SQLiteRepository) PruneDeliveries(ctx context.Context, age time.Duration) (int64, error) {
+	ctx, done := observe(ctx, "PruneDeliveries")
+	defer done()
+	if age <= 0 {
+		return 0, errors.NewValidationError("age must be positive")
+	}
+
+	cutoff := time.Now().Add(-age).UTC().Format("2006-01-02 15:04:05")
+	result, err := r.db.ExecContext(ctx, pruneDeliveriesSQL, cutoff)
+	if err != nil {
+		return 0, errors.NewDatabaseError(err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.NewDatabaseError(err)
+	}
+	return deleted, nil
+}
+
+// queryDeliveries runs a query selecting deliveryColumns
+func (r *SQLiteRepository) queryDeliveries(ctx context.Context, query string, args ...any) ([]*models.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	defer rows.Close()
+
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, errors.NewDatabaseError(err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	return deliveries, nil
+}
+
+// scanWebhook reads a webhook selected with webhookColumns
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	var events string
+	if err := row.Scan(&webhook.ID, &webhook.URL, &events, &webhook.Secret, &webhook.CreatedAt); err != nil {
+		return nil, err
+	}
+	webhook.Events = strings.Split(events, ",")
+	return webhook, nil
+}
+
+// scanDelivery reads a delivery selected with deliveryColumns
+func scanDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{}
+	var deliveredAt sql.NullTime
+	if err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.Event,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&deliveredAt,
+	); err != nil {
+		return nil, err
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return delivery, nil
+}
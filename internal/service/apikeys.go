@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	apperrors "github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/repository"
+	"github.com/nijaru/nano-link/internal/tracing"
+)
+
+const (
+	// apiKeyPrefix starts every API key secret, making leaked keys easy to spot
+	apiKeyPrefix = "nl_"
+
+	// apiKeyBytes is the amount of randomness in a secret
+	apiKeyBytes = 24
+
+	// maxAPIKeyNameLength bounds the name of an API key
+	maxAPIKeyNameLength = 100
+)
+
+// APIKeyService provides business logic for API keys
+type APIKeyService struct {
+	repo repository.APIKeyRepository
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(repo repository.APIKeyRepository) APIKeyService {
+	return APIKeyService{repo: repo}
+}
+
+// CreateKey creates an API key and returns it with its secret, which is not
+// stored and cannot be shown again
+func (s *APIKeyService) CreateKey(ctx context.Context, name string) (*models.APIKey, string, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.CreateKey")
+	defer span.End()
+
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAPIKeyNameLength {
+		return nil, "", apperrors.NewValidationError("Key name must be between 1 and 100 characters")
+	}
+
+	random := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(random); err != nil {
+		return nil, "", apperrors.WithMessage(err, "failed to generate API key")
+	}
+	secret := apiKeyPrefix + hex.EncodeToString(random)
+
+	key := &models.APIKey{
+		Name:    name,
+		Prefix:  secret[:len(apiKeyPrefix)+8],
+		KeyHash: hashAPIKey(secret),
+	}
+	if err := s.repo.CreateAPIKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+// ListKeys retrieves all API keys, including revoked ones
+func (s *APIKeyService) ListKeys(ctx context.Context) ([]*models.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.ListKeys")
+	defer span.End()
+
+	return s.repo.ListAPIKeys(ctx)
+}
+
+// RevokeKey revokes an API key
+func (s *APIKeyService) RevokeKey(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "APIKeyService.RevokeKey")
+	defer span.End()
+
+	return s.repo.RevokeAPIKey(ctx, id)
+}
+
+// Authenticate returns the active API key with the given secret
+func (s *APIKeyService) Authenticate(ctx context.Context, secret string) (*models.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Authenticate")
+	defer span.End()
+
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, apperrors.NewSecurityError("Invalid API key")
+	}
+
+	key, err := s.repo.GetAPIKeyByHash(ctx, hashAPIKey(secret))
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewSecurityError("Invalid API key")
+		}
+		return nil, err
+	}
+	if key.Revoked() {
+		return nil, apperrors.NewSecurityError("API key has been revoked")
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, key.ID); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// hashAPIKey hashes a secret for storage. Secrets are random, so a fast hash
+// is enough.
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
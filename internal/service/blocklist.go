@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+
+	"github.com/nijaru/nano-link/internal/blocklist"
+	apperrors "github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/tracing"
+)
+
+// blocklistPageSize is the number of links checked per query when existing
+// links are re-checked against the blocklist
+const blocklistPageSize = 500
+
+// WithBlocklist rejects destinations matching the blocklist
+func WithBlocklist(list *blocklist.Blocklist) Option {
+	return func(s *URLService) {
+		s.blocklist = list
+	}
+}
+
+// checkBlocklist rejects destinations matching the blocklist
+func (s *URLService) checkBlocklist(destinations []string) error {
+	for _, destination := range destinations {
+		if _, blocked := s.blocklist.Match(destination); blocked {
+			return apperrors.NewSecurityError("Destination is blocked")
+		}
+	}
+	return nil
+}
+
+// blockedPattern returns the blocklist pattern matching any destination of url
+func (s *URLService) blockedPattern(url *models.URL) (string, bool) {
+	for _, destination := range linkDestinations(url) {
+		if pattern, blocked := s.blocklist.Match(destination); blocked {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// RecheckBlocklist disables existing links whose destinations match the
+// blocklist and returns how many were disabled
+func (s *URLService) RecheckBlocklist(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "URLService.RecheckBlocklist")
+	defer span.End()
+
+	if s.blocklist.Size() == 0 {
+		return 0, nil
+	}
+
+	var disabled int64
+	var afterID int64
+	for {
+		urls, err := s.repo.ListEnabledURLs(ctx, afterID, blocklistPageSize)
+		if err != nil {
+			return disabled, err
+		}
+
+		for _, url := range urls {
+			pattern, blocked := s.blockedPattern(url)
+			if !blocked {
+				continue
+			}
+			reason := "blocklist: " + pattern
+			if err := s.repo.DisableURL(ctx, url.ID, reason); err != nil {
+				return disabled, err
+			}
+			url.Disabled, url.DisabledReason = true, reason
+			publishEvent(ctx, s.events, models.EventLinkDisabled, url)
+			disabled++
+		}
+
+		if len(urls) < blocklistPageSize {
+			return disabled, nil
+		}
+		afterID = urls[len(urls)-1].ID
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	apperrors "github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+)
+
+// maxCardImageURLLength bounds the image URL of a social card
+const maxCardImageURLLength = 2048
+
+// SocialCardFor returns the card shown when a link is shared: its custom
+// card, with the link's title and description filling in missing fields.
+// It is nil when there is nothing to show.
+func SocialCardFor(link *models.URL) *models.SocialCard {
+	var card models.SocialCard
+	if link.Card != nil {
+		card = *link.Card
+	}
+	if card.Title == "" {
+		card.Title = link.Title
+	}
+	if card.Description == "" {
+		card.Description = link.Description
+	}
+	if card == (models.SocialCard{}) {
+		return nil
+	}
+	return &card
+}
+
+// validateCard trims the fields of a social card and checks them. An empty
+// card is returned as nil.
+func validateCard(card *models.SocialCard) (*models.SocialCard, error) {
+	if card == nil {
+		return nil, nil
+	}
+
+	clean := models.SocialCard{
+		Title:       cleanText(card.Title),
+		Description: strings.TrimSpace(card.Description),
+		ImageURL:    strings.TrimSpace(card.ImageURL),
+	}
+	if utf8.RuneCountInString(clean.Title) > maxTitleLength {
+		return nil, apperrors.NewValidationError("Card title is too long (max 200 characters)")
+	}
+	if utf8.RuneCountInString(clean.Description) > maxDescriptionLength {
+		return nil, apperrors.NewValidationError("Card description is too long (max 1000 characters)")
+	}
+	if clean.ImageURL != "" {
+		if len(clean.ImageURL) > maxCardImageURLLength {
+			return nil, apperrors.NewValidationError("Card image URL is too long (max 2048 characters)")
+		}
+		u, err := url.Parse(clean.ImageURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+			return nil, apperrors.NewValidationError("Card image URL must be an http or https URL")
+		}
+		clean.ImageURL = u.String()
+	}
+
+	if clean == (models.SocialCard{}) {
+		return nil, nil
+	}
+	return &clean, nil
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	apperrors "github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/repository"
+	"github.com/nijaru/nano-link/internal/tracing"
+)
+
+const (
+	// Limits for collection content
+	maxCollectionItems    = 100
+	maxCollectionTitle    = 100
+	maxCollectionDesc     = 500
+	maxItemTitle          = 100
+	maxTextIconLength     = 16
+	maxImageIconURLLength = 2048
+)
+
+// slugRegex matches a valid collection slug
+var slugRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{3,32}$`)
+
+// CollectionService provides business logic for link-in-bio collections
+type CollectionService struct {
+	repo repository.CollectionRepository
+	urls repository.URLRepository
+}
+
+// NewCollectionService creates a new collection service
+func NewCollectionService(repo repository.CollectionRepository, urls repository.URLRepository) CollectionService {
+	return CollectionService{repo: repo, urls: urls}
+}
+
+// CreateCollectionRequest represents the data needed to create a collection
+type CreateCollectionRequest struct {
+	Slug        string                  `json:"slug"`
+	Title       string                  `json:"title"`
+	Description string                  `json:"description,omitempty"`
+	Items       []CollectionItemRequest `json:"items,omitempty"`
+}
+
+// CollectionItemRequest represents a short URL to list in a collection
+type CollectionItemRequest struct {
+	Code   string `json:"code"`
+	Domain string `json:"domain,omitempty"` // custom domain of the code; empty for the default
+	Title  string `json:"title"`
+	Icon   string `json:"icon,omitempty"`
+}
+
+// CreateCollection creates a new collection
+func (s *CollectionService) CreateCollection(ctx context.Context, req CreateCollectionRequest) (*models.Collection, error) {
+	ctx, span := tracing.Start(ctx, "CollectionService.CreateCollection")
+	defer span.End()
+
+	if !slugRegex.MatchString(req.Slug) {
+		return nil, apperrors.NewValidationError("Slug must be 3-32 letters, digits, '-' or '_'")
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" || len(title) > maxCollectionTitle {
+		return nil, apperrors.NewValidationError("Title must be between 1 and 100 characters")
+	}
+
+	description := strings.TrimSpace(req.Description)
+	if len(description) > maxCollectionDesc {
+		return nil, apperrors.NewValidationError("Description is too long (max 500 characters)")
+	}
+
+	if len(req.Items) > maxCollectionItems {
+		return nil, apperrors.NewValidationError("Too many items (max 100)")
+	}
+
+	exists, err := s.repo.CollectionExists(ctx, req.Slug)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, apperrors.NewValidationError("Slug already in use")
+	}
+
+	collection := &models.Collection{
+		Slug:        req.Slug,
+		Title:       title,
+		Description: description,
+		Items:       make([]*models.CollectionItem, 0, len(req.Items)),
+	}
+	for _, itemReq := range req.Items {
+		item, err := s.buildItem(ctx, itemReq)
+		if err != nil {
+			return nil, err
+		}
+		collection.Items = append(collection.Items, item)
+	}
+
+	if err := s.repo.CreateCollection(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	return collection, nil
+}
+
+// GetCollection retrieves a collection with its items
+func (s *CollectionService) GetCollection(ctx context.Context, slug string) (*models.Collection, error) {
+	ctx, span := tracing.Start(ctx, "CollectionService.GetCollection")
+	defer span.End()
+
+	if slug == "" {
+		return nil, apperrors.NewValidationError("slug cannot be empty")
+	}
+	return s.repo.GetCollection(ctx, slug)
+}
+
+// DeleteCollection deletes a collection. The listed short URLs are kept.
+func (s *CollectionService) DeleteCollection(ctx context.Context, slug string) error {
+	ctx, span := tracing.Start(ctx, "CollectionService.DeleteCollection")
+	defer span.End()
+
+	if slug == "" {
+		return apperrors.NewValidationError("slug cannot be empty")
+	}
+	return s.repo.DeleteCollection(ctx, slug)
+}
+
+// AddItem appends a short URL to a collection
+func (s *CollectionService) AddItem(ctx context.Context, slug string, req CollectionItemRequest) (*models.CollectionItem, error) {
+	ctx, span := tracing.Start(ctx, "CollectionService.AddItem")
+	defer span.End()
+
+	collection, err := s.GetCollection(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if len(collection.Items) >= maxCollectionItems {
+		return nil, apperrors.NewValidationError("Too many items (max 100)")
+	}
+
+	item, err := s.buildItem(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	item.CollectionID = collection.ID
+
+	if err := s.repo.AddCollectionItem(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// RemoveItem removes an item from a collection
+func (s *CollectionService) RemoveItem(ctx context.Context, slug string, itemID int64) error {
+	ctx, span := tracing.Start(ctx, "CollectionService.RemoveItem")
+	defer span.End()
+
+	collection, err := s.GetCollection(ctx, slug)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteCollectionItem(ctx, collection.ID, itemID)
+}
+
+// ReorderItems orders a collection's items by itemIDs, which must list
+// every item exactly once
+func (s *CollectionService) ReorderItems(ctx context.Context, slug string, itemIDs []int64) (*models.Collection, error) {
+	ctx, span := tracing.Start(ctx, "CollectionService.ReorderItems")
+	defer span.End()
+
+	collection, err := s.GetCollection(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(itemIDs) != len(collection.Items) {
+		return nil, apperrors.NewValidationError("Item order must list every item exactly once")
+	}
+	known := make(map[int64]bool, len(collection.Items))
+	for _, item := range collection.Items {
+		known[item.ID] = true
+	}
+	for _, id := range itemIDs {
+		if !known[id] {
+			return nil, apperrors.NewValidationError("Item order must list every item exactly once")
+		}
+		delete(known, id)
+	}
+
+	if err := s.repo.ReorderCollectionItems(ctx, collection.ID, itemIDs); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCollection(ctx, slug)
+}
+
+// RecordItemClick attributes a click to a collection item and returns the item
+func (s *CollectionService) RecordItemClick(ctx context.Context, slug string, itemID int64) (*models.CollectionItem, error) {
+	ctx, span := tracing.Start(ctx, "CollectionService.RecordItemClick")
+	defer span.End()
+
+	collection, err := s.GetCollection(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range collection.Items {
+		if item.ID == itemID {
+			if err := s.repo.IncrementItemClicks(ctx, collection.ID, itemID); err != nil {
+				return nil, err
+			}
+			return item, nil
+		}
+	}
+
+	return nil, apperrors.NewNotFoundError("Collection item not found")
+}
+
+// buildItem validates an item request and resolves its short URL
+func (s *CollectionService) buildItem(ctx context.Context, req CollectionItemRequest) (*models.CollectionItem, error) {
+	if req.Code == "" {
+		return nil, apperrors.NewValidationError("Item code cannot be empty")
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" || len(title) > maxItemTitle {
+		return nil, apperrors.NewValidationError("Item title must be between 1 and 100 characters")
+	}
+
+	icon := strings.TrimSpace(req.Icon)
+	if err := validateIcon(icon); err != nil {
+		return nil, err
+	}
+
+	url, err := s.urls.GetByCode(ctx, NormalizeHost(req.Domain), req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CollectionItem{
+		URLID:     url.ID,
+		ShortCode: url.ShortCode,
+		Domain:    url.Domain,
+		Title:     title,
+		Icon:      icon,
+	}, nil
+}
+
+// validateIcon accepts an https image URL or a short text icon such as an emoji
+func validateIcon(icon string) error {
+	if icon == "" {
+		return nil
+	}
+	if IsWebURL(icon) {
+		if !strings.HasPrefix(strings.ToLower(icon), "https://") || len(icon) > maxImageIconURLLength {
+			return apperrors.NewValidationError("Icon URL must be an https URL")
+		}
+		return nil
+	}
+	if len(icon) > maxTextIconLength {
+		return apperrors.NewValidationError("Icon must be an https image URL or short text")
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	apperrors "github.com/nijaru/nano-link/internal/errors"
+)
+
+// destinationLookupTimeout bounds the DNS lookup of a destination host
+const destinationLookupTimeout = 3 * time.Second
+
+// numericLabelRegex matches a final host label that browsers parse as part of
+// an IPv4 address, e.g. the "1" in "127.1" or "0x7f000001"
+var numericLabelRegex = regexp.MustCompile(`^(0[xX][0-9a-fA-F]*|[0-9]+)$`)
+
+// reservedNetworks are never valid destinations unless allowlisted: loopback,
+// private, link-local, shared, documentation, multicast and other special ranges
+var reservedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"192.88.99.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+	"100::/64",
+	"2001::/32",
+	"2001:db8::/32",
+	"2002::/16",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+// mustParseCIDRs parses a fixed list of CIDR ranges
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = ipNet
+	}
+	return nets
+}
+
+// IsReservedIP reports whether ip is in a loopback, private, link-local or
+// otherwise reserved range
+func IsReservedIP(ip net.IP) bool {
+	for _, ipNet := range reservedNetworks {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// DestinationAllowlist exempts intranet hosts and networks from the
+// destination policy
+type DestinationAllowlist struct {
+	hosts    map[string]bool
+	suffixes []string // ".corp.example.com" for "*.corp.example.com"
+	networks []*net.IPNet
+}
+
+// ParseDestinationAllowlist parses hostnames, "*.suffix" wildcards, IP
+// addresses and CIDR ranges
+func ParseDestinationAllowlist(entries []string) (DestinationAllowlist, error) {
+	list := DestinationAllowlist{hosts: make(map[string]bool)}
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case strings.Contains(entry, "/"):
+			_, ipNet, err := net.ParseCIDR(entry)
+			if err != nil {
+				return DestinationAllowlist{}, fmt.Errorf("invalid allowlist range %q", entry)
+			}
+			list.networks = append(list.networks, ipNet)
+		case net.ParseIP(entry) != nil:
+			ip, bits := net.ParseIP(entry), 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			list.networks = append(list.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		case strings.HasPrefix(entry, "*."):
+			list.suffixes = append(list.suffixes, entry[1:])
+		default:
+			list.hosts[strings.TrimSuffix(entry, ".")] = true
+		}
+	}
+	return list, nil
+}
+
+// allowsHost reports whether host matches an allowlisted name
+func (l DestinationAllowlist) allowsHost(host string) bool {
+	if l.hosts[host] {
+		return true
+	}
+	for _, suffix := range l.suffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsIP reports whether ip is in an allowlisted network
+func (l DestinationAllowlist) allowsIP(ip net.IP) bool {
+	for _, ipNet := range l.networks {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithDestinationAllowlist exempts intranet hosts and networks from the
+// private address checks on destinations
+func WithDestinationAllowlist(list DestinationAllowlist) Option {
+	return func(s *URLService) {
+		s.allowlist = list
+	}
+}
+
+// validateDestinationHost rejects destinations that point at loopback,
+// private or reserved addresses, either directly or, with resolve, through
+// DNS
+func (s *URLService) validateDestinationHost(ctx context.Context, host string, resolve bool) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if s.allowlist.allowsHost(host) {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return s.validateDestinationIP(ip)
+	}
+
+	// Hosts like "2130706433", "0x7f.1" or "127.1" are IPv4 addresses to a
+	// browser but not to net.ParseIP
+	labels := strings.Split(host, ".")
+	if strings.Contains(host, ":") || numericLabelRegex.MatchString(labels[len(labels)-1]) {
+		return apperrors.NewValidationError("URL host is not a valid domain or IP address")
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return apperrors.NewValidationError("URL must not point to a private or reserved address")
+	}
+	if !resolve {
+		return nil
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, destinationLookupTimeout)
+	defer cancel()
+
+	addrs, err := s.lookupIP(lookupCtx, host)
+	if err != nil || len(addrs) == 0 {
+		return apperrors.NewValidationError("URL host could not be resolved")
+	}
+	for _, addr := range addrs {
+		if err := s.validateDestinationIP(addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateDestinationIP rejects reserved addresses that are not allowlisted
+func (s *URLService) validateDestinationIP(ip net.IP) error {
+	if IsReservedIP(ip) && !s.allowlist.allowsIP(ip) {
+		return apperrors.NewValidationError("URL must not point to a private or reserved address")
+	}
+	return nil
+}
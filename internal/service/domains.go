@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"strings"
+
+	apperrors "github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/repository"
+	"github.com/nijaru/nano-link/internal/tracing"
+)
+
+// hostnameRegex matches a fully qualified lowercase hostname
+var hostnameRegex = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// DomainService provides business logic for custom domains
+type DomainService struct {
+	repo        repository.DomainRepository
+	defaultHost string
+}
+
+// NewDomainService creates a new domain service. defaultHost is the host of
+// BASE_URL, which always serves the default namespace and cannot be registered.
+func NewDomainService(repo repository.DomainRepository, defaultHost string) DomainService {
+	return DomainService{repo: repo, defaultHost: NormalizeHost(defaultHost)}
+}
+
+// NormalizeHost lowercases a host and strips any port and trailing dot
+func NormalizeHost(host string) string {
+	host = strings.TrimSpace(strings.ToLower(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+// CreateDomain registers a custom domain
+func (s *DomainService) CreateDomain(ctx context.Context, host string) (*models.Domain, error) {
+	ctx, span := tracing.Start(ctx, "DomainService.CreateDomain")
+	defer span.End()
+
+	host = NormalizeHost(host)
+	if !hostnameRegex.MatchString(host) {
+		return nil, apperrors.NewValidationError("Invalid domain name")
+	}
+	if host == s.defaultHost {
+		return nil, apperrors.NewValidationError("The default domain does not need to be registered")
+	}
+
+	exists, err := s.repo.DomainExists(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, apperrors.NewValidationError("Domain already registered")
+	}
+
+	domain := &models.Domain{Host: host}
+	if err := s.repo.CreateDomain(ctx, domain); err != nil {
+		return nil, err
+	}
+	return domain, nil
+}
+
+// ListDomains retrieves all custom domains
+func (s *DomainService) ListDomains(ctx context.Context) ([]*models.Domain, error) {
+	ctx, span := tracing.Start(ctx, "DomainService.ListDomains")
+	defer span.End()
+
+	return s.repo.ListDomains(ctx)
+}
+
+// DeleteDomain removes a custom domain that no longer has any links
+func (s *DomainService) DeleteDomain(ctx context.Context, host string) error {
+	ctx, span := tracing.Start(ctx, "DomainService.DeleteDomain")
+	defer span.End()
+
+	host = NormalizeHost(host)
+
+	inUse, err := s.repo.DomainInUse(ctx, host)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return apperrors.NewValidationError("Domain still has short URLs")
+	}
+
+	return s.repo.DeleteDomain(ctx, host)
+}
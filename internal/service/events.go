@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/nijaru/nano-link/internal/models"
+)
+
+// EventPublisher sends link events to the webhooks subscribed to them
+type EventPublisher interface {
+	// Publish queues an event for delivery. The change the event describes
+	// has already been made, so publishers handle their own failures.
+	Publish(ctx context.Context, event *models.LinkEvent)
+
+	// Subscribed reports whether any webhook receives an event
+	Subscribed(ctx context.Context, event string) bool
+}
+
+// WithEvents publishes link lifecycle and click events
+func WithEvents(events EventPublisher) Option {
+	return func(s *URLService) {
+		s.events = events
+	}
+}
+
+// publishEvent publishes an event about a link, if events are enabled
+func publishEvent(ctx context.Context, events EventPublisher, event string, url *models.URL) {
+	if events == nil {
+		return
+	}
+	events.Publish(ctx, NewLinkEvent(event, url))
+}
+
+// NewLinkEvent describes an event that just happened to a link
+func NewLinkEvent(event string, url *models.URL) *models.LinkEvent {
+	return &models.LinkEvent{Event: event, OccurredAt: time.Now().UTC(), URL: url}
+}
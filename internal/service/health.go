@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/tracing"
+)
+
+const (
+	// healthCheckTimeout bounds each destination check
+	healthCheckTimeout = 10 * time.Second
+
+	// healthCheckUserAgent identifies health check requests to destination sites
+	healthCheckUserAgent = "nano-link-health-checker/1.0"
+
+	// maxHealthErrorLength bounds the error recorded for a failed check
+	maxHealthErrorLength = 200
+)
+
+// HealthTarget returns the web page checked for url: its destination, or the
+// fallback of a deep link. It is empty when the link has no web page.
+func HealthTarget(url *models.URL) string {
+	if IsWebURL(url.OriginalURL) {
+		return url.OriginalURL
+	}
+	return url.FallbackURL
+}
+
+// CheckDestination requests the web page of url and reports its health.
+// Servers that reject HEAD are retried with GET. Redirects are not followed
+// and count as healthy.
+func (s *URLService) CheckDestination(ctx context.Context, url *models.URL) *models.LinkHealth {
+	ctx, span := tracing.Start(ctx, "URLService.CheckDestination")
+	defer span.End()
+
+	client := s.safeHTTPClient(healthCheckTimeout)
+	target := HealthTarget(url)
+
+	start := time.Now()
+	status, err := s.requestStatus(ctx, client, http.MethodHead, target)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = s.requestStatus(ctx, client, http.MethodGet, target)
+	}
+
+	health := &models.LinkHealth{
+		StatusCode: status,
+		LatencyMS:  time.Since(start).Milliseconds(),
+		CheckedAt:  time.Now(),
+	}
+	if err != nil {
+		health.StatusCode = 0
+		health.Error = describeCheckError(err)
+	}
+	return health
+}
+
+// RecordHealth stores the result of a destination check
+func (s *URLService) RecordHealth(ctx context.Context, url *models.URL, health *models.LinkHealth) error {
+	ctx, span := tracing.Start(ctx, "URLService.RecordHealth")
+	defer span.End()
+
+	return s.repo.UpdateURLHealth(ctx, url.ID, health)
+}
+
+// requestStatus sends one request and returns the response status
+func (s *URLService) requestStatus(ctx context.Context, client *http.Client, method, target string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", healthCheckUserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// describeCheckError summarizes why a check failed
+func describeCheckError(err error) string {
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return "timeout"
+	}
+	msg := err.Error()
+	if len(msg) > maxHealthErrorLength {
+		msg = msg[:maxHealthErrorLength]
+	}
+	return msg
+}
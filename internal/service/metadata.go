@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	apperrors "github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/tracing"
+	"golang.org/x/net/html"
+)
+
+const (
+	// Length limits of the descriptive fields of a link
+	maxTitleLength       = 200
+	maxDescriptionLength = 1000
+	maxNotesLength       = 2000
+
+	// metadataFetchTimeout bounds a metadata fetch, including redirects
+	metadataFetchTimeout = 10 * time.Second
+
+	// maxMetadataBytes bounds how much of a page is read looking for metadata
+	maxMetadataBytes = 512 << 10
+
+	// metadataUserAgent identifies metadata requests to destination sites
+	metadataUserAgent = "Mozilla/5.0 (compatible; nano-link-metadata/1.0)"
+)
+
+// PageMetadata is the title and description read from a web page
+type PageMetadata struct {
+	Title       string
+	Description string
+}
+
+// UpdateURLRequest changes the descriptive fields of a short URL. Nil fields
+// are left unchanged; empty strings clear them. A card replaces the whole
+// social card, and an empty one removes it.
+type UpdateURLRequest struct {
+	Title       *string            `json:"title"`
+	Description *string            `json:"description"`
+	Notes       *string            `json:"notes"`
+	Card        *models.SocialCard `json:"card"`
+}
+
+// WithMetadataCapture marks new links so that the title and description of
+// their destination page are fetched in the background
+func WithMetadataCapture(enabled bool) Option {
+	return func(s *URLService) {
+		s.captureMetadata = enabled
+	}
+}
+
+// UpdateURL changes the title, description, notes or social card of a short
+// URL and returns the updated URL
+func (s *URLService) UpdateURL(ctx context.Context, domain, code string, req UpdateURLRequest) (*models.URL, error) {
+	ctx, span := tracing.Start(ctx, "URLService.UpdateURL")
+	defer span.End()
+
+	url, err := s.GetURL(ctx, domain, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title != nil {
+		url.Title = *req.Title
+	}
+	if req.Description != nil {
+		url.Description = *req.Description
+	}
+	if req.Notes != nil {
+		url.Notes = *req.Notes
+	}
+	if url.Title, url.Description, url.Notes, err = validateDetails(url.Title, url.Description, url.Notes); err != nil {
+		return nil, err
+	}
+	if req.Card != nil {
+		if url.Card, err = validateCard(req.Card); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.UpdateURLDetails(ctx, url); err != nil {
+		return nil, err
+	}
+	publishEvent(ctx, s.events, models.EventLinkUpdated, url)
+	return url, nil
+}
+
+// FetchMetadata requests a web page and reads its title and description
+// from the <title> element and OpenGraph tags. Redirects are followed up to
+// maxRedirectDepth, and every hop must pass the destination policy.
+func (s *URLService) FetchMetadata(ctx context.Context, target string) (*PageMetadata, error) {
+	ctx, span := tracing.Start(ctx, "URLService.FetchMetadata")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, metadataFetchTimeout)
+	defer cancel()
+
+	client := s.safeHTTPClient(metadataFetchTimeout)
+	for hops := 0; ; hops++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", metadataUserAgent)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if location, err := resp.Location(); err == nil && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+			resp.Body.Close()
+			if hops >= maxRedirectDepth {
+				return nil, apperrors.NewValidationError("Destination redirects too many times")
+			}
+			if target, err = s.validateAndSanitizeURL(ctx, location.String(), true); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		defer resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return nil, fmt.Errorf("destination returned status %d", resp.StatusCode)
+		}
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+			return nil, fmt.Errorf("destination is not an HTML page (%s)", mediaType)
+		}
+		return parseMetadata(io.LimitReader(resp.Body, maxMetadataBytes)), nil
+	}
+}
+
+// RecordMetadata stores fetched page metadata in the empty fields of a URL
+// and marks its metadata as fetched. A nil meta only clears the mark.
+func (s *URLService) RecordMetadata(ctx context.Context, url *models.URL, meta *PageMetadata) error {
+	ctx, span := tracing.Start(ctx, "URLService.RecordMetadata")
+	defer span.End()
+
+	if meta == nil {
+		meta = &PageMetadata{}
+	}
+	return s.repo.RecordURLMetadata(ctx, url.ID, meta.Title, meta.Description)
+}
+
+// parseMetadata reads the title and description from the head of a page.
+// OpenGraph tags take precedence over <title> and the description meta tag.
+func parseMetadata(r io.Reader) *PageMetadata {
+	var title, ogTitle, description, ogDescription string
+
+	z := html.NewTokenizer(r)
+	for done := false; !done; {
+		switch z.Next() {
+		case html.ErrorToken:
+			done = true
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			done = string(name) == "head"
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "body":
+				done = true
+			case "title":
+				if title == "" && z.Next() == html.TextToken {
+					title = string(z.Text())
+				}
+			case "meta":
+				var key, content string
+				for hasAttr {
+					var attr, value []byte
+					attr, value, hasAttr = z.TagAttr()
+					switch string(attr) {
+					case "property", "name":
+						key = strings.ToLower(string(value))
+					case "content":
+						content = string(value)
+					}
+				}
+				switch key {
+				case "og:title":
+					ogTitle = content
+				case "og:description":
+					ogDescription = content
+				case "description":
+					description = content
+				}
+			}
+		}
+	}
+
+	if ogTitle != "" {
+		title = ogTitle
+	}
+	if ogDescription != "" {
+		description = ogDescription
+	}
+	return &PageMetadata{
+		Title:       truncateText(cleanText(title), maxTitleLength),
+		Description: truncateText(cleanText(description), maxDescriptionLength),
+	}
+}
+
+// validateDetails trims the descriptive fields of a link and checks their length
+func validateDetails(title, description, notes string) (string, string, string, error) {
+	title = cleanText(title)
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		return "", "", "", apperrors.NewValidationError("Title is too long (max 200 characters)")
+	}
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(description) > maxDescriptionLength {
+		return "", "", "", apperrors.NewValidationError("Description is too long (max 1000 characters)")
+	}
+	notes = strings.TrimSpace(notes)
+	if utf8.RuneCountInString(notes) > maxNotesLength {
+		return "", "", "", apperrors.NewValidationError("Notes are too long (max 2000 characters)")
+	}
+	return title, description, notes, nil
+}
+
+// cleanText collapses runs of whitespace and drops invalid UTF-8
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(strings.ToValidUTF8(s, "")), " ")
+}
+
+// truncateText shortens s to at most max runes
+func truncateText(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"syscall"
+	"time"
+
+	apperrors "github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+)
+
+const (
+	// maxRedirectDepth bounds how many of our own links, or external
+	// shortener hops, a destination may pass through
+	maxRedirectDepth = 5
+
+	// shortenerTimeout bounds each request made to expand a shortener link
+	shortenerTimeout = 5 * time.Second
+)
+
+// linkPathRegex matches the path of a short link, its "+" preview or its
+// preview page
+var linkPathRegex = regexp.MustCompile(`^/([A-Za-z0-9_-]+)(\+|/preview)?/?$`)
+
+// knownShorteners are hosts whose links are expanded when shortener
+// expansion is enabled
+var knownShorteners = map[string]bool{
+	"bit.ly":      true,
+	"buff.ly":     true,
+	"cutt.ly":     true,
+	"goo.gl":      true,
+	"is.gd":       true,
+	"ow.ly":       true,
+	"rb.gy":       true,
+	"rebrand.ly":  true,
+	"shorturl.at": true,
+	"t.co":        true,
+	"t.ly":        true,
+	"tiny.cc":     true,
+	"tinyurl.com": true,
+	"v.gd":        true,
+}
+
+// WithDefaultHost sets the host of BASE_URL, so destinations pointing back at
+// the default domain can be recognized
+func WithDefaultHost(host string) Option {
+	return func(s *URLService) {
+		s.defaultHost = NormalizeHost(host)
+	}
+}
+
+// WithShortenerExpansion follows links on known external shorteners when a
+// short URL is created and stores the final destination instead
+func WithShortenerExpansion(enabled bool) Option {
+	return func(s *URLService) {
+		s.expandShorteners = enabled
+	}
+}
+
+// ownDomain reports whether host is served by this instance and returns the
+// domain of its code namespace. requestHost is the host the request creating
+// the link was sent to; like the BASE_URL host, it serves the default
+// namespace unless it is a registered domain.
+func (s *URLService) ownDomain(ctx context.Context, host, requestHost string) (string, bool, error) {
+	host = NormalizeHost(host)
+	if host == "" {
+		return "", false, nil
+	}
+	if host == s.defaultHost {
+		return "", true, nil
+	}
+
+	if s.domains != nil {
+		exists, err := s.domains.DomainExists(ctx, host)
+		if err != nil {
+			return "", false, err
+		}
+		if exists {
+			return host, true, nil
+		}
+	}
+	return "", host == NormalizeHost(requestHost), nil
+}
+
+// ownLink returns the domain and code of destination when it is a short link
+// on this instance
+func (s *URLService) ownLink(ctx context.Context, destination, requestHost string) (string, string, bool, error) {
+	if !IsWebURL(destination) {
+		return "", "", false, nil
+	}
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", "", false, nil
+	}
+
+	domain, own, err := s.ownDomain(ctx, u.Hostname(), requestHost)
+	if err != nil || !own {
+		return "", "", false, err
+	}
+	m := linkPathRegex.FindStringSubmatch(u.Path)
+	if m == nil {
+		return "", "", false, nil // Home page, collections and other non-link pages
+	}
+	return domain, m[1], true, nil
+}
+
+// linkDestinations returns every destination a link can redirect to
+func linkDestinations(url *models.URL) []string {
+	destinations := []string{url.OriginalURL}
+	if url.FallbackURL != "" {
+		destinations = append(destinations, url.FallbackURL)
+	}
+	for _, target := range url.GeoTargets {
+		destinations = append(destinations, target)
+	}
+	return destinations
+}
+
+// checkRedirectLoop follows destinations that are short links on this
+// instance through every destination of each target link, and rejects
+// missing targets, loops back to the link being created (domain, code) and
+// chains longer than maxRedirectDepth
+func (s *URLService) checkRedirectLoop(ctx context.Context, destination, domain, code, requestHost string) error {
+	onPath := map[string]bool{}
+	if code != "" {
+		onPath[domain+"/"+code] = true
+	}
+	checked := map[string]bool{}
+
+	var follow func(destination string, depth int) error
+	follow = func(destination string, depth int) error {
+		targetDomain, targetCode, own, err := s.ownLink(ctx, destination, requestHost)
+		if err != nil || !own {
+			return err
+		}
+
+		key := targetDomain + "/" + targetCode
+		if onPath[key] {
+			return apperrors.NewValidationError("Destination redirects back to this link")
+		}
+		if checked[key] {
+			return nil
+		}
+		if depth >= maxRedirectDepth {
+			return apperrors.NewValidationError("Destination redirects through too many short links")
+		}
+
+		target, err := s.repo.GetByCode(ctx, targetDomain, targetCode)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrNotFound) {
+				return apperrors.NewValidationError("Destination is a short link that does not exist")
+			}
+			return err
+		}
+
+		onPath[key] = true
+		for _, next := range linkDestinations(target) {
+			if err := follow(next, depth+1); err != nil {
+				return err
+			}
+		}
+		onPath[key] = false
+		checked[key] = true
+		return nil
+	}
+	return follow(destination, 0)
+}
+
+// expandShortener follows redirects from known external shorteners and
+// returns the final destination. Every hop passes the destination policy; a
+// shortener that cannot be reached leaves the destination as it is.
+func (s *URLService) expandShortener(ctx context.Context, destination string) (string, error) {
+	if !s.expandShorteners {
+		return destination, nil
+	}
+
+	client := s.safeHTTPClient(shortenerTimeout)
+	seen := map[string]bool{}
+	for hops := 0; ; hops++ {
+		u, err := url.Parse(destination)
+		if err != nil || !IsWebURL(destination) || !knownShorteners[NormalizeHost(u.Hostname())] {
+			return destination, nil
+		}
+		if seen[destination] {
+			return "", apperrors.NewValidationError("Shortened destination redirects in a loop")
+		}
+		if hops >= maxRedirectDepth {
+			return "", apperrors.NewValidationError("Shortened destination redirects too many times")
+		}
+		seen[destination] = true
+
+		resp, err := shortenerResponse(ctx, client, http.MethodHead, destination)
+		if err == nil && resp.StatusCode >= 400 {
+			// Some shorteners only answer GET
+			resp, err = shortenerResponse(ctx, client, http.MethodGet, destination)
+		}
+		if err != nil || resp.StatusCode < 300 || resp.StatusCode >= 400 {
+			return destination, nil
+		}
+		location, err := resp.Location()
+		if err != nil {
+			return destination, nil
+		}
+
+		destination, err = s.validateAndSanitizeURL(ctx, location.String(), true)
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// shortenerResponse sends one request to a shortener link and returns the
+// response with its body closed
+func shortenerResponse(ctx context.Context, client *http.Client, method, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// safeHTTPClient returns a client that does not follow redirects and refuses
+// to connect to reserved addresses, even if DNS changed after validation
+func (s *URLService) safeHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return apperrors.NewValidationError("URL host is not a valid domain or IP address")
+			}
+			return s.validateDestinationIP(ip)
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			DisableKeepAlives:   true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
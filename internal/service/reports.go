@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	apperrors "github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/repository"
+	"github.com/nijaru/nano-link/internal/tracing"
+)
+
+const (
+	// maxReportDetailsLength bounds the free-form details of a report
+	maxReportDetailsLength = 1000
+
+	// maxUserAgentLength bounds the stored reporter user agent
+	maxUserAgentLength = 300
+
+	// reportDisabledPrefix starts the disabled reason of links disabled by reports
+	reportDisabledPrefix = "reports: "
+)
+
+// reportReasons are the accepted report categories
+var reportReasons = map[string]bool{
+	"phishing": true,
+	"malware":  true,
+	"spam":     true,
+	"illegal":  true,
+	"other":    true,
+}
+
+// ReportService provides business logic for abuse reports
+type ReportService struct {
+	repo      repository.ReportRepository
+	urls      repository.URLRepository
+	threshold int            // distinct reporters that disable a link; 0 never disables
+	events    EventPublisher // optional
+}
+
+// NewReportService creates a new report service. Links disabled and
+// re-enabled by reports are published as events unless events is nil.
+func NewReportService(repo repository.ReportRepository, urls repository.URLRepository, threshold int, events EventPublisher) ReportService {
+	return ReportService{repo: repo, urls: urls, threshold: threshold, events: events}
+}
+
+// CreateReportRequest represents a public report of a short URL
+type CreateReportRequest struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+	Email   string `json:"email"`
+}
+
+// Reporter describes who submitted a report
+type Reporter struct {
+	IP        string
+	UserAgent string
+}
+
+// CreateReport stores a report on a short URL and disables the URL once
+// enough distinct reporters have open reports on it
+func (s *ReportService) CreateReport(ctx context.Context, domain, code string, req CreateReportRequest, reporter Reporter) (*models.Report, error) {
+	ctx, span := tracing.Start(ctx, "ReportService.CreateReport")
+	defer span.End()
+
+	reason := strings.ToLower(strings.TrimSpace(req.Reason))
+	if !reportReasons[reason] {
+		return nil, apperrors.NewValidationError("Reason must be phishing, malware, spam, illegal or other")
+	}
+
+	details := strings.TrimSpace(req.Details)
+	if len(details) > maxReportDetailsLength {
+		return nil, apperrors.NewValidationError("Details are too long (max 1000 characters)")
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if email != "" {
+		if _, err := mail.ParseAddress(email); err != nil {
+			return nil, apperrors.NewValidationError("Invalid email address")
+		}
+	}
+
+	url, err := s.urls.GetByCode(ctx, NormalizeHost(domain), code)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicate, err := s.repo.HasOpenReport(ctx, url.ID, reporter.IP)
+	if err != nil {
+		return nil, err
+	}
+	if duplicate {
+		return nil, apperrors.NewValidationError("You have already reported this link")
+	}
+
+	userAgent := reporter.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	report := &models.Report{
+		URLID:         url.ID,
+		ShortCode:     url.ShortCode,
+		Domain:        url.Domain,
+		Destination:   url.OriginalURL,
+		Reason:        reason,
+		Details:       details,
+		ReporterEmail: email,
+		ReporterIP:    reporter.IP,
+		UserAgent:     userAgent,
+		Status:        models.ReportOpen,
+	}
+	if err := s.repo.CreateReport(ctx, report); err != nil {
+		return nil, err
+	}
+
+	if s.threshold > 0 && !url.Disabled {
+		reporters, err := s.repo.CountOpenReporters(ctx, url.ID)
+		if err != nil {
+			return nil, err
+		}
+		if reporters >= s.threshold {
+			reason := fmt.Sprintf("%s%d open reports", reportDisabledPrefix, reporters)
+			if err := s.urls.DisableURL(ctx, url.ID, reason); err != nil {
+				return nil, err
+			}
+			url.Disabled, url.DisabledReason = true, reason
+			publishEvent(ctx, s.events, models.EventLinkDisabled, url)
+		}
+	}
+
+	return report, nil
+}
+
+// ListReports retrieves the newest reports with a status, or all reports if status is empty
+func (s *ReportService) ListReports(ctx context.Context, status string, limit int) ([]*models.Report, error) {
+	ctx, span := tracing.Start(ctx, "ReportService.ListReports")
+	defer span.End()
+
+	switch status {
+	case "", models.ReportOpen, models.ReportDismissed, models.ReportConfirmed:
+	default:
+		return nil, apperrors.NewValidationError("status must be open, dismissed or confirmed")
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.repo.ListReports(ctx, status, limit)
+}
+
+// DismissReport closes a report as unfounded. A link disabled by reports is
+// re-enabled once the remaining open reports fall below the threshold.
+func (s *ReportService) DismissReport(ctx context.Context, id int64) (*models.Report, error) {
+	ctx, span := tracing.Start(ctx, "ReportService.DismissReport")
+	defer span.End()
+
+	report, err := s.repo.GetReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ResolveReport(ctx, id, models.ReportDismissed); err != nil {
+		return nil, err
+	}
+
+	url, err := s.urls.GetByCode(ctx, report.Domain, report.ShortCode)
+	if err != nil {
+		return nil, err
+	}
+	if url.Disabled && strings.HasPrefix(url.DisabledReason, reportDisabledPrefix) {
+		reporters, err := s.repo.CountOpenReporters(ctx, url.ID)
+		if err != nil {
+			return nil, err
+		}
+		if reporters < s.threshold {
+			if err := s.urls.EnableURL(ctx, url.ID); err != nil {
+				return nil, err
+			}
+			url.Disabled, url.DisabledReason = false, ""
+			publishEvent(ctx, s.events, models.EventLinkEnabled, url)
+		}
+	}
+
+	return s.repo.GetReport(ctx, id)
+}
+
+// ConfirmReport confirms a report, disables the link and closes every other
+// open report on it
+func (s *ReportService) ConfirmReport(ctx context.Context, id int64) (*models.Report, error) {
+	ctx, span := tracing.Start(ctx, "ReportService.ConfirmReport")
+	defer span.End()
+
+	report, err := s.repo.GetReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != models.ReportOpen {
+		return nil, apperrors.NewValidationError("Report is already resolved")
+	}
+
+	url, err := s.urls.GetByCode(ctx, report.Domain, report.ShortCode)
+	if err != nil {
+		return nil, err
+	}
+	reason := "reported: " + report.Reason
+	if err := s.urls.DisableURL(ctx, url.ID, reason); err != nil {
+		return nil, err
+	}
+	if !url.Disabled {
+		url.Disabled, url.DisabledReason = true, reason
+		publishEvent(ctx, s.events, models.EventLinkDisabled, url)
+	}
+	if err := s.repo.ResolveOpenReports(ctx, report.URLID, models.ReportConfirmed); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetReport(ctx, id)
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+
+	apperrors "github.com/nijaru/nano-link/internal/errors"
+)
+
+var (
+	// schemeRegex captures a leading URL scheme such as "https" or "myapp"
+	schemeRegex = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*):`)
+
+	// hostPortRegex matches "host:port" input that only looks like a scheme
+	hostPortRegex = regexp.MustCompile(`^[^/:]+:[0-9]+([/?#]|$)`)
+
+	// phoneRegex matches the number part of tel: and sms: links
+	phoneRegex = regexp.MustCompile(`^\+?[0-9().\- ]{3,32}$`)
+)
+
+// blockedSchemes can run code or read local data in the browser and are
+// rejected even if an operator adds them to the allowlist
+var blockedSchemes = map[string]bool{
+	"javascript": true,
+	"vbscript":   true,
+	"data":       true,
+	"file":       true,
+	"blob":       true,
+	"about":      true,
+}
+
+// WithAllowedSchemes permits destinations using the given non-HTTP schemes,
+// such as mailto, tel or custom app schemes
+func WithAllowedSchemes(schemes []string) Option {
+	return func(s *URLService) {
+		s.schemes = make(map[string]bool, len(schemes))
+		for _, scheme := range schemes {
+			scheme = strings.ToLower(strings.TrimSpace(scheme))
+			if scheme == "" || scheme == "http" || scheme == "https" || blockedSchemes[scheme] {
+				continue
+			}
+			s.schemes[scheme] = true
+		}
+	}
+}
+
+// IsWebURL reports whether a destination uses http or https
+func IsWebURL(urlStr string) bool {
+	lower := strings.ToLower(urlStr)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// splitScheme returns the lowercased scheme of urlStr and whether the input
+// should be treated as having one. Input like "example.com:8080/path" matches
+// the scheme syntax but is really a host and port.
+func splitScheme(urlStr string) (string, bool) {
+	m := schemeRegex.FindStringSubmatch(urlStr)
+	if m == nil || hostPortRegex.MatchString(urlStr) {
+		return "", false
+	}
+	return strings.ToLower(m[1]), true
+}
+
+// validateSchemeURL validates a destination using an allowlisted non-HTTP scheme
+func validateSchemeURL(scheme, urlStr string) (string, error) {
+	if strings.ContainsAny(urlStr, " \t\r\n") {
+		return "", apperrors.NewValidationError("URL cannot contain whitespace")
+	}
+
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return "", apperrors.NewValidationError("Invalid URL format")
+	}
+
+	switch scheme {
+	case "mailto":
+		if u.Opaque == "" {
+			return "", apperrors.NewValidationError("mailto link must include an email address")
+		}
+		for _, addr := range strings.Split(u.Opaque, ",") {
+			unescaped, err := url.PathUnescape(addr)
+			if err != nil {
+				return "", apperrors.NewValidationError("Invalid email address in mailto link")
+			}
+			if _, err := mail.ParseAddress(unescaped); err != nil {
+				return "", apperrors.NewValidationError("Invalid email address in mailto link")
+			}
+		}
+	case "tel", "sms":
+		number, err := url.PathUnescape(u.Opaque)
+		if err != nil || !phoneRegex.MatchString(number) {
+			return "", apperrors.NewValidationError("Invalid phone number")
+		}
+	default:
+		// App deep links only need something to open after the scheme
+		if u.Opaque == "" && u.Host == "" && strings.Trim(u.Path, "/") == "" {
+			return "", apperrors.NewValidationError("Deep link must include a target after the scheme")
+		}
+	}
+
+	return urlStr, nil
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	apperrors "github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/repository"
+	"github.com/nijaru/nano-link/internal/tracing"
+)
+
+const (
+	// maxTagsPerURL bounds the number of tags on one link
+	maxTagsPerURL = 20
+
+	// maxTagLength bounds the length of a tag
+	maxTagLength = 50
+
+	// maxFolderLength bounds the length of a folder name
+	maxFolderLength = 100
+)
+
+// tagRegex matches a normalized tag
+var tagRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// TagService provides business logic for tags and folders
+type TagService struct {
+	repo   repository.TagRepository
+	urls   repository.URLRepository
+	events EventPublisher // optional
+}
+
+// NewTagService creates a new tag service. Changes are published as
+// link.updated events unless events is nil.
+func NewTagService(repo repository.TagRepository, urls repository.URLRepository, events EventPublisher) TagService {
+	return TagService{repo: repo, urls: urls, events: events}
+}
+
+// AddTags adds tags to a short URL and returns the updated URL
+func (s *TagService) AddTags(ctx context.Context, domain, code string, tags []string) (*models.URL, error) {
+	ctx, span := tracing.Start(ctx, "TagService.AddTags")
+	defer span.End()
+
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, apperrors.NewValidationError("At least one tag is required")
+	}
+
+	url, err := s.urls.GetByCode(ctx, NormalizeHost(domain), code)
+	if err != nil {
+		return nil, err
+	}
+
+	combined, err := normalizeTags(append(url.Tags, tags...))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.AddURLTags(ctx, url.ID, tags); err != nil {
+		return nil, err
+	}
+	url.Tags = combined
+	publishEvent(ctx, s.events, models.EventLinkUpdated, url)
+	return url, nil
+}
+
+// RemoveTag removes a tag from a short URL
+func (s *TagService) RemoveTag(ctx context.Context, domain, code, tag string) error {
+	ctx, span := tracing.Start(ctx, "TagService.RemoveTag")
+	defer span.End()
+
+	url, err := s.urls.GetByCode(ctx, NormalizeHost(domain), code)
+	if err != nil {
+		return err
+	}
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if err := s.repo.RemoveURLTag(ctx, url.ID, tag); err != nil {
+		return err
+	}
+
+	remaining := url.Tags[:0]
+	for _, t := range url.Tags {
+		if t != tag {
+			remaining = append(remaining, t)
+		}
+	}
+	url.Tags = remaining
+	publishEvent(ctx, s.events, models.EventLinkUpdated, url)
+	return nil
+}
+
+// ListTags retrieves every tag in use with the number of links and visits
+func (s *TagService) ListTags(ctx context.Context) ([]*models.TagStats, error) {
+	ctx, span := tracing.Start(ctx, "TagService.ListTags")
+	defer span.End()
+
+	return s.repo.ListTags(ctx)
+}
+
+// GetTagStats retrieves usage statistics of the links with a tag
+func (s *TagService) GetTagStats(ctx context.Context, tag string) (*models.Stats, error) {
+	ctx, span := tracing.Start(ctx, "TagService.GetTagStats")
+	defer span.End()
+
+	tags, err := normalizeTags([]string{tag})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetTagStats(ctx, tags[0])
+}
+
+// SetFolder moves a short URL into a folder, or out of any folder if folder
+// is empty, and returns the updated URL
+func (s *TagService) SetFolder(ctx context.Context, domain, code, folder string) (*models.URL, error) {
+	ctx, span := tracing.Start(ctx, "TagService.SetFolder")
+	defer span.End()
+
+	folder, err := normalizeFolder(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := s.urls.GetByCode(ctx, NormalizeHost(domain), code)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetURLFolder(ctx, url.ID, folder); err != nil {
+		return nil, err
+	}
+	url.Folder = folder
+	publishEvent(ctx, s.events, models.EventLinkUpdated, url)
+	return url, nil
+}
+
+// ListFolders retrieves every folder in use with the number of links and visits
+func (s *TagService) ListFolders(ctx context.Context) ([]*models.FolderStats, error) {
+	ctx, span := tracing.Start(ctx, "TagService.ListFolders")
+	defer span.End()
+
+	return s.repo.ListFolders(ctx)
+}
+
+// normalizeTags lowercases, deduplicates and sorts tags, rejecting invalid ones
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if len(tag) > maxTagLength || !tagRegex.MatchString(tag) {
+			return nil, apperrors.NewValidationError("Tags must be 1 to 50 letters, digits, '-' or '_', starting with a letter or digit")
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > maxTagsPerURL {
+		return nil, apperrors.NewValidationError("A link can have at most 20 tags")
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// normalizeFolder trims a folder name and rejects invalid ones. Folder names
+// may contain "/" to suggest nesting.
+func normalizeFolder(folder string) (string, error) {
+	folder = strings.Trim(strings.TrimSpace(folder), "/")
+	if len(folder) > maxFolderLength {
+		return "", apperrors.NewValidationError("Folder is too long (max 100 characters)")
+	}
+	if strings.IndexFunc(folder, unicode.IsControl) >= 0 {
+		return "", apperrors.NewValidationError("Folder contains invalid characters")
+	}
+	return folder, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// pruneThreshold is the number of tracked keys above which expired windows are dropped
+const pruneThreshold = 1024
+
+// attemptLimiter counts failed attempts per key and blocks a key once it
+// reaches max failures within window. State is kept in memory only.
+type attemptLimiter struct {
+	mu      sync.Mutex
+	max     int
+	window  time.Duration
+	entries map[string]*attemptWindow
+}
+
+// attemptWindow tracks failures for a single key
+type attemptWindow struct {
+	failures int
+	started  time.Time
+}
+
+// newAttemptLimiter creates a limiter allowing max failures per window
+func newAttemptLimiter(max int, window time.Duration) *attemptLimiter {
+	return &attemptLimiter{
+		max:     max,
+		window:  window,
+		entries: make(map[string]*attemptWindow),
+	}
+}
+
+// Allowed reports whether another attempt may be made for key
+func (l *attemptLimiter) Allowed(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		return true
+	}
+	if time.Since(entry.started) >= l.window {
+		delete(l.entries, key)
+		return true
+	}
+	return entry.failures < l.max
+}
+
+// Fail records a failed attempt for key
+func (l *attemptLimiter) Fail(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := l.entries[key]
+	if !ok || now.Sub(entry.started) >= l.window {
+		if len(l.entries) >= pruneThreshold {
+			l.prune(now)
+		}
+		l.entries[key] = &attemptWindow{failures: 1, started: now}
+		return
+	}
+	entry.failures++
+}
+
+// Reset clears the failures recorded for key
+func (l *attemptLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, key)
+}
+
+// prune drops expired windows so the map does not grow without bound.
+// Callers must hold l.mu.
+func (l *attemptLimiter) prune(now time.Time) {
+	for key, entry := range l.entries {
+		if now.Sub(entry.started) >= l.window {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	apperrors "github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/tracing"
+)
+
+// ExportedURL is a URL as written by an export: everything needed to restore
+// it, including the password hash and expiry exemption the API never shows
+type ExportedURL struct {
+	models.URL
+	PasswordHash string `json:"password_hash,omitempty"`
+	NoExpire     bool   `json:"no_expire,omitempty"`
+}
+
+// NewExportedURL prepares url for export
+func NewExportedURL(url *models.URL) ExportedURL {
+	return ExportedURL{URL: *url, PasswordHash: url.PasswordHash, NoExpire: url.NoExpire}
+}
+
+// importedCodeRegex matches the short codes accepted from other shorteners,
+// which may be shorter or longer than the codes users can choose here
+var importedCodeRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ImportURL stores an exported URL under its original code, keeping its
+// creation time, visit count, password and disabled state. Destinations are
+// checked for syntax and against the blocklist, without DNS lookups.
+func (s *URLService) ImportURL(ctx context.Context, exported ExportedURL) (*models.URL, error) {
+	ctx, span := tracing.Start(ctx, "URLService.ImportURL")
+	defer span.End()
+
+	url, err := s.PrepareImport(ctx, exported)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, url); err != nil {
+		return nil, err
+	}
+	return url, nil
+}
+
+// PrepareImport validates an exported URL and returns the URL that ImportURL
+// would store, without storing it. Codes already in use fail with an error
+// wrapping ErrCodeConflict.
+func (s *URLService) PrepareImport(ctx context.Context, exported ExportedURL) (*models.URL, error) {
+	ctx, span := tracing.Start(ctx, "URLService.PrepareImport")
+	defer span.End()
+
+	if exported.ShortCode == "" {
+		return nil, apperrors.NewValidationError("Short code is required")
+	}
+	if !importedCodeRegex.MatchString(exported.ShortCode) || reservedCodes[strings.ToLower(exported.ShortCode)] {
+		return nil, apperrors.NewValidationError("Invalid short code format")
+	}
+	if exported.Visits < 0 {
+		return nil, apperrors.NewValidationError("Visits cannot be negative")
+	}
+	if exported.CreatedAt.After(time.Now()) {
+		return nil, apperrors.NewValidationError("Creation time cannot be in the future")
+	}
+	if exported.PasswordHash != "" && !strings.HasPrefix(exported.PasswordHash, "$2") {
+		return nil, apperrors.NewValidationError("Password hash must be a bcrypt hash")
+	}
+
+	url, err := s.newURL(ctx, CreateURLRequest{
+		URL:          exported.OriginalURL,
+		CustomCode:   exported.ShortCode,
+		GeoTargets:   exported.GeoTargets,
+		Owner:        exported.Owner,
+		Interstitial: exported.Interstitial,
+		FallbackURL:  exported.FallbackURL,
+		Domain:       exported.Domain,
+		Tags:         exported.Tags,
+		Folder:       exported.Folder,
+		// Other shorteners allow longer titles
+		Title:       truncateText(cleanText(exported.Title), maxTitleLength),
+		Description: exported.Description,
+		Notes:       exported.Notes,
+		Card:        exported.Card,
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	url.PasswordHash = exported.PasswordHash
+	url.Protected = url.PasswordHash != ""
+	url.Visits = exported.Visits
+	url.LastVisitedAt = exported.LastVisitedAt
+	url.NoExpire = exported.NoExpire
+	if !exported.CreatedAt.IsZero() {
+		url.CreatedAt = exported.CreatedAt
+	}
+	if exported.Disabled {
+		url.Disabled = true
+		url.DisabledReason = exported.DisabledReason
+		if url.DisabledReason == "" {
+			url.DisabledReason = "imported"
+		}
+	}
+	return url, nil
+}
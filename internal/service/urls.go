@@ -4,61 +4,429 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"net"
 	"net/url"
 	"regexp"
+	"strings"
 	"time"
 
+	"github.com/nijaru/nano-link/internal/blocklist"
 	apperrors "github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/geoip"
+	"github.com/nijaru/nano-link/internal/metrics"
 	"github.com/nijaru/nano-link/internal/models"
 	"github.com/nijaru/nano-link/internal/repository"
+	"github.com/nijaru/nano-link/internal/tracing"
+	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	// maxGeoTargets bounds the number of per-country destinations on one URL
+	maxGeoTargets = 50
+
+	// Password length limits; bcrypt ignores input beyond 72 bytes
+	minPasswordLength = 4
+	maxPasswordLength = 72
+
+	// Default brute-force throttling for password-protected links
+	defaultUnlockAttempts = 5
+	defaultUnlockWindow   = 15 * time.Minute
+
+	// maxOwnerLength bounds the free-form owner recorded on a link
+	maxOwnerLength = 100
+
+	// Defaults for interstitial pages
+	defaultInterstitialCountdown = 5
+	defaultInterstitialWarning   = "You are about to leave this site. Only continue if you trust the destination."
+)
+
+// InterstitialConfig controls the interstitial page shown before redirecting
+type InterstitialConfig struct {
+	Always    bool   // show the interstitial for every link
+	Countdown int    // seconds before redirecting automatically
+	Warning   string // text shown above the destination
+}
+
 // URLService provides business logic for URL operations
 type URLService struct {
-	repo repository.URLRepository
+	repo         repository.URLRepository
+	geo          *geoip.Resolver
+	unlock       *attemptLimiter
+	interstitial InterstitialConfig
+	schemes      map[string]bool // allowed non-HTTP destination schemes
+	domains      repository.DomainRepository
+	allowlist    DestinationAllowlist
+	lookupIP     func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+	defaultHost      string // host of BASE_URL, for self-reference checks
+	expandShorteners bool
+	captureMetadata  bool
+	blocklist        *blocklist.Blocklist
+	events           EventPublisher
+}
+
+// Option configures optional URLService dependencies
+type Option func(*URLService)
+
+// WithGeoIP enables country lookups for geo targeting and visit breakdowns
+func WithGeoIP(geo *geoip.Resolver) Option {
+	return func(s *URLService) {
+		s.geo = geo
+	}
+}
+
+// WithPasswordThrottle limits failed password attempts per link
+func WithPasswordThrottle(maxAttempts int, window time.Duration) Option {
+	return func(s *URLService) {
+		s.unlock = newAttemptLimiter(maxAttempts, window)
+	}
+}
+
+// WithInterstitial configures the interstitial page shown before redirecting
+func WithInterstitial(cfg InterstitialConfig) Option {
+	return func(s *URLService) {
+		if cfg.Countdown < 0 {
+			cfg.Countdown = 0
+		}
+		if cfg.Warning == "" {
+			cfg.Warning = defaultInterstitialWarning
+		}
+		s.interstitial = cfg
+	}
+}
+
+// WithDomains enables custom domains, each with its own namespace of codes
+func WithDomains(domains repository.DomainRepository) Option {
+	return func(s *URLService) {
+		s.domains = domains
+	}
 }
 
 // NewURLService creates a new URL service
-func NewURLService(repo repository.URLRepository) URLService {
-	return URLService{repo: repo}
+func NewURLService(repo repository.URLRepository, opts ...Option) URLService {
+	s := URLService{
+		repo:     repo,
+		unlock:   newAttemptLimiter(defaultUnlockAttempts, defaultUnlockWindow),
+		lookupIP: net.DefaultResolver.LookupIPAddr,
+		interstitial: InterstitialConfig{
+			Countdown: defaultInterstitialCountdown,
+			Warning:   defaultInterstitialWarning,
+		},
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
 }
 
-// GetURL retrieves a URL by its short code
-func (s *URLService) GetURL(ctx context.Context, code string) (*models.URL, error) {
+// GetURL retrieves a URL by its domain and short code. An empty domain is
+// the default domain.
+func (s *URLService) GetURL(ctx context.Context, domain, code string) (*models.URL, error) {
+	ctx, span := tracing.Start(ctx, "URLService.GetURL")
+	defer span.End()
+
 	if code == "" {
 		return nil, apperrors.NewValidationError("code cannot be empty")
 	}
-	return s.repo.GetByCode(ctx, code)
+	return s.repo.GetByCode(ctx, NormalizeHost(domain), code)
 }
 
-// IncrementVisits increments the visit counter for a URL
-func (s *URLService) IncrementVisits(ctx context.Context, code string) error {
-	if code == "" {
-		return apperrors.NewValidationError("code cannot be empty")
+// ResolveDomain maps a request Host to the domain whose codes it serves.
+// Hosts that are not registered custom domains serve the default domain.
+func (s *URLService) ResolveDomain(ctx context.Context, host string) (string, error) {
+	ctx, span := tracing.Start(ctx, "URLService.ResolveDomain")
+	defer span.End()
+
+	if s.domains == nil {
+		return "", nil
 	}
-	return s.repo.IncrementVisits(ctx, code)
+
+	host = NormalizeHost(host)
+	if host == "" {
+		return "", nil
+	}
+
+	exists, err := s.domains.DomainExists(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", nil
+	}
+	return host, nil
 }
 
-// GetRecentURLs retrieves recent URLs with pagination
-func (s *URLService) GetRecentURLs(ctx context.Context, limit int) ([]*models.URL, error) {
-	if limit <= 0 {
-		limit = 10 // Default to 10 if not specified
+// validateDomain checks that a requested domain is registered
+func (s *URLService) validateDomain(ctx context.Context, domain string) (string, error) {
+	domain = NormalizeHost(domain)
+	if domain == "" {
+		return "", nil
+	}
+
+	resolved, err := s.ResolveDomain(ctx, domain)
+	if err != nil {
+		return "", err
+	}
+	if resolved == "" {
+		return "", apperrors.NewValidationError("Unknown domain")
+	}
+	return resolved, nil
+}
+
+// ShowInterstitial reports whether visitors should see the interstitial page
+// instead of being redirected immediately
+func (s *URLService) ShowInterstitial(url *models.URL) bool {
+	return url.Interstitial || s.interstitial.Always
+}
+
+// GetPreview describes a short URL for the preview and interstitial pages.
+// The destination of a password-protected link is never included.
+func (s *URLService) GetPreview(ctx context.Context, domain, code, country string) (*models.Preview, error) {
+	ctx, span := tracing.Start(ctx, "URLService.GetPreview")
+	defer span.End()
+
+	url, err := s.GetURL(ctx, domain, code)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &models.Preview{
+		ShortCode:    url.ShortCode,
+		Domain:       url.Domain,
+		Owner:        url.Owner,
+		Visits:       url.Visits,
+		CreatedAt:    url.CreatedAt,
+		Protected:    url.Protected,
+		Interstitial: s.ShowInterstitial(url),
+		Disabled:     url.Disabled,
+		Countdown:    s.interstitial.Countdown,
+		Warning:      s.interstitial.Warning,
+	}
+	if !url.Protected && !url.Disabled {
+		preview.Destination = s.DestinationFor(url, country)
+		preview.FallbackURL = url.FallbackURL
+	}
+
+	return preview, nil
+}
+
+// Unlock verifies the password of a protected URL and returns the URL on success.
+// Failed attempts are throttled per link.
+func (s *URLService) Unlock(ctx context.Context, domain, code, password string) (*models.URL, error) {
+	ctx, span := tracing.Start(ctx, "URLService.Unlock")
+	defer span.End()
+
+	url, err := s.GetURL(ctx, domain, code)
+	if err != nil {
+		return nil, err
+	}
+	if !url.Protected {
+		return url, nil
+	}
+
+	key := url.Domain + "/" + url.ShortCode
+	if !s.unlock.Allowed(key) {
+		return nil, apperrors.NewRateLimitError("Too many incorrect passwords. Please try again later.")
 	}
-	return s.repo.GetRecentURLs(ctx, limit)
+
+	if err := bcrypt.CompareHashAndPassword([]byte(url.PasswordHash), []byte(password)); err != nil {
+		s.unlock.Fail(key)
+		return nil, apperrors.NewSecurityError("Incorrect password")
+	}
+
+	s.unlock.Reset(key)
+	return url, nil
+}
+
+// LookupCountry returns the country code for a client IP, or an empty string
+// when GeoIP is not configured or the address is unknown
+func (s *URLService) LookupCountry(ip string) string {
+	return s.geo.Country(ip)
+}
+
+// DestinationFor returns where visitors from country should be sent
+func (s *URLService) DestinationFor(url *models.URL, country string) string {
+	if target, ok := url.GeoTargets[country]; ok && country != "" {
+		return target
+	}
+	return url.OriginalURL
+}
+
+// RecordVisit increments the visit counter and the per-country breakdown
+func (s *URLService) RecordVisit(ctx context.Context, url *models.URL, country string) error {
+	ctx, span := tracing.Start(ctx, "URLService.RecordVisit")
+	defer span.End()
+
+	if err := s.repo.IncrementVisits(ctx, url.ID); err != nil {
+		return err
+	}
+	if country != "" {
+		if err := s.repo.RecordCountryVisit(ctx, url.ID, country); err != nil {
+			return err
+		}
+	}
+
+	if s.events != nil {
+		event := NewLinkEvent(models.EventLinkClicked, url)
+		event.Country = country
+		s.events.Publish(ctx, event)
+	}
+	return nil
+}
+
+// GetCountryVisits retrieves the per-country visit breakdown for a URL
+func (s *URLService) GetCountryVisits(ctx context.Context, domain, code string) ([]*models.CountryVisits, error) {
+	ctx, span := tracing.Start(ctx, "URLService.GetCountryVisits")
+	defer span.End()
+
+	url, err := s.GetURL(ctx, domain, code)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetCountryVisits(ctx, url.ID)
+}
+
+// URLPage is one page of a URL listing
+type URLPage struct {
+	URLs       []*models.URL
+	NextCursor string // empty on the last page
+}
+
+// pageCursor is the decoded form of an opaque listing cursor. It records the
+// sort order so that a cursor cannot be used with a different one.
+type pageCursor struct {
+	Sort      string `json:"s"`
+	Ascending bool   `json:"a,omitempty"`
+	Value     string `json:"v"`
+	ID        int64  `json:"i"`
+}
+
+// GetRecentURLs retrieves a page of URLs matching a filter. An empty cursor
+// starts at the first page; NextCursor continues after the returned page.
+func (s *URLService) GetRecentURLs(ctx context.Context, filter repository.URLFilter, cursor string) (*URLPage, error) {
+	ctx, span := tracing.Start(ctx, "URLService.GetRecentURLs")
+	defer span.End()
+
+	if filter.Limit <= 0 {
+		filter.Limit = 10 // Default to 10 if not specified
+	}
+	switch filter.Health {
+	case "", models.HealthBroken, models.HealthHealthy, models.HealthUnchecked:
+	default:
+		return nil, apperrors.NewValidationError("health must be broken, healthy or unchecked")
+	}
+	switch filter.Sort {
+	case "":
+		filter.Sort = repository.SortCreated
+	case repository.SortCreated, repository.SortVisits, repository.SortLastVisited:
+	default:
+		return nil, apperrors.NewValidationError("sort must be created, visits or last_visited")
+	}
+	switch filter.Status {
+	case "", repository.StatusActive, repository.StatusDisabled:
+	default:
+		return nil, apperrors.NewValidationError("status must be active or disabled")
+	}
+	if !filter.CreatedAfter.IsZero() && !filter.CreatedBefore.IsZero() && !filter.CreatedAfter.Before(filter.CreatedBefore) {
+		return nil, apperrors.NewValidationError("created_after must be before created_before")
+	}
+	filter.Tag = strings.ToLower(strings.TrimSpace(filter.Tag))
+	if filter.Folder != "" {
+		folder, err := normalizeFolder(filter.Folder)
+		if err != nil {
+			return nil, err
+		}
+		filter.Folder = folder
+	}
+
+	if cursor != "" {
+		decoded, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if decoded.Sort != filter.Sort || decoded.Ascending != filter.Ascending {
+			return nil, apperrors.NewValidationError("Cursor does not match the sort order")
+		}
+		filter.After = &repository.URLCursor{Value: decoded.Value, ID: decoded.ID}
+	}
+
+	// Fetch one extra URL to find out whether another page follows
+	limit := filter.Limit
+	filter.Limit++
+	urls, err := s.repo.GetRecentURLs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &URLPage{URLs: urls}
+	if len(urls) > limit {
+		page.URLs = urls[:limit]
+		last := repository.CursorFor(filter.Sort, page.URLs[limit-1])
+		page.NextCursor = encodeCursor(pageCursor{
+			Sort:      filter.Sort,
+			Ascending: filter.Ascending,
+			Value:     last.Value,
+			ID:        last.ID,
+		})
+	}
+	return page, nil
+}
+
+// encodeCursor makes a cursor opaque to clients
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reverses encodeCursor
+func decodeCursor(cursor string) (pageCursor, error) {
+	var decoded pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || json.Unmarshal(data, &decoded) != nil || decoded.ID <= 0 {
+		return decoded, apperrors.NewValidationError("Invalid cursor")
+	}
+	return decoded, nil
 }
 
 // GetStats retrieves usage statistics
 func (s *URLService) GetStats(ctx context.Context) (*models.Stats, error) {
+	ctx, span := tracing.Start(ctx, "URLService.GetStats")
+	defer span.End()
+
 	return s.repo.GetStats(ctx)
 }
 
 // CreateURLRequest represents the data needed to create a short URL
 type CreateURLRequest struct {
-	URL        string `json:"url"`
-	CustomCode string `json:"custom_code,omitempty"`
+	URL          string             `json:"url"`
+	CustomCode   string             `json:"custom_code,omitempty"`
+	GeoTargets   map[string]string  `json:"geo_targets,omitempty"` // country code -> destination
+	Password     string             `json:"password,omitempty"`
+	Owner        string             `json:"owner,omitempty"`
+	Interstitial bool               `json:"interstitial,omitempty"`
+	FallbackURL  string             `json:"fallback_url,omitempty"` // web page for non-HTTP destinations
+	Domain       string             `json:"domain,omitempty"`       // registered custom domain; empty for the default
+	Tags         []string           `json:"tags,omitempty"`
+	Folder       string             `json:"folder,omitempty"`
+	Title        string             `json:"title,omitempty"`
+	Description  string             `json:"description,omitempty"`
+	Notes        string             `json:"notes,omitempty"`
+	Card         *models.SocialCard `json:"card,omitempty"`
+
+	// RequestHost is the host the request was sent to, so destinations
+	// pointing back at it are recognized as links on this instance
+	RequestHost string `json:"-"`
 }
 
+// ErrCodeConflict marks a validation error caused by a short code that is
+// already in use on its domain
+var ErrCodeConflict = errors.New("short code already in use")
+
+// countryCodeRegex matches an ISO 3166-1 alpha-2 country code
+var countryCodeRegex = regexp.MustCompile(`^[A-Z]{2}$`)
+
 // generateShortCode generates a cryptographically secure random short code
 func generateShortCode() (string, error) {
 	b := make([]byte, 6)
@@ -68,8 +436,9 @@ func generateShortCode() (string, error) {
 	return base64.URLEncoding.EncodeToString(b)[:6], nil
 }
 
-// validateAndSanitizeURL validates and sanitizes a URL
-func (s *URLService) validateAndSanitizeURL(urlStr string) (string, error) {
+// validateAndSanitizeURL validates and sanitizes a URL. Without resolve, the
+// host is checked without DNS lookups.
+func (s *URLService) validateAndSanitizeURL(ctx context.Context, urlStr string, resolve bool) (string, error) {
 	if urlStr == "" {
 		return "", apperrors.NewValidationError("URL cannot be empty")
 	}
@@ -78,9 +447,14 @@ func (s *URLService) validateAndSanitizeURL(urlStr string) (string, error) {
 		return "", apperrors.NewValidationError("URL is too long (max 2048 characters)")
 	}
 
-	// Add scheme if missing
-	if !regexp.MustCompile(`^https?://`).MatchString(urlStr) {
+	// Add scheme if missing; non-HTTP schemes must be allowlisted
+	if scheme, ok := splitScheme(urlStr); !ok {
 		urlStr = "http://" + urlStr
+	} else if scheme != "http" && scheme != "https" {
+		if blockedSchemes[scheme] || !s.schemes[scheme] {
+			return "", apperrors.NewValidationError("URL scheme is not allowed")
+		}
+		return validateSchemeURL(scheme, urlStr)
 	}
 
 	u, err := url.Parse(urlStr)
@@ -92,22 +466,61 @@ func (s *URLService) validateAndSanitizeURL(urlStr string) (string, error) {
 		return "", apperrors.NewValidationError("URL must have a host")
 	}
 
-	// Ensure URL has TLD or is localhost
-	hostParts := regexp.MustCompile(`\.`).Split(u.Host, -1)
-	if len(hostParts) < 2 && u.Host != "localhost" {
+	// Credentials in the authority can disguise the real host, as in
+	// "https://trusted.example@evil.example"
+	if u.User != nil {
+		return "", apperrors.NewValidationError("URL must not contain credentials")
+	}
+
+	// Ensure URL has TLD unless the host is an IP address or allowlisted
+	hostParts := regexp.MustCompile(`\.`).Split(u.Hostname(), -1)
+	if len(hostParts) < 2 && net.ParseIP(u.Hostname()) == nil && !s.allowlist.allowsHost(strings.ToLower(u.Hostname())) {
 		return "", apperrors.NewValidationError("URL must have a valid domain")
 	}
 
+	if err := s.validateDestinationHost(ctx, u.Hostname(), resolve); err != nil {
+		return "", err
+	}
+
 	return urlStr, nil
 }
 
+// validateFallbackURL validates the web fallback for a non-HTTP destination
+func (s *URLService) validateFallbackURL(ctx context.Context, destination, fallback string, resolve bool) (string, error) {
+	if fallback == "" {
+		return "", nil
+	}
+	if IsWebURL(destination) {
+		return "", apperrors.NewValidationError("Fallback URL is only supported for non-HTTP destinations")
+	}
+
+	cleanFallback, err := s.validateAndSanitizeURL(ctx, fallback, resolve)
+	if err != nil {
+		return "", err
+	}
+	if !IsWebURL(cleanFallback) {
+		return "", apperrors.NewValidationError("Fallback URL must be an http or https URL")
+	}
+	return cleanFallback, nil
+}
+
+// reservedCodes are paths served by routes registered before the redirect
+// route, so links with these codes could never be reached
+var reservedCodes = map[string]bool{
+	"api":     true,
+	"health":  true,
+	"livez":   true,
+	"metrics": true,
+	"readyz":  true,
+}
+
 // isValidCustomCode validates a custom short code
 func isValidCustomCode(code string) bool {
 	if code == "" {
 		return false
 	}
 
-	if len(code) < 4 || len(code) > 12 {
+	if len(code) < 4 || len(code) > 12 || reservedCodes[strings.ToLower(code)] {
 		return false
 	}
 
@@ -116,64 +529,322 @@ func isValidCustomCode(code string) bool {
 	return match
 }
 
+// validateGeoTargets normalizes country codes and validates each destination
+func (s *URLService) validateGeoTargets(ctx context.Context, targets map[string]string, resolve bool) (map[string]string, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	if len(targets) > maxGeoTargets {
+		return nil, apperrors.NewValidationError("Too many geo targets (max 50)")
+	}
+
+	clean := make(map[string]string, len(targets))
+	for country, target := range targets {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if !countryCodeRegex.MatchString(country) {
+			return nil, apperrors.NewValidationError("Geo target country must be a two-letter ISO code")
+		}
+		cleanTarget, err := s.validateAndSanitizeURL(ctx, target, resolve)
+		if err != nil {
+			return nil, err
+		}
+		clean[country] = cleanTarget
+	}
+
+	return clean, nil
+}
+
+// hashPassword validates and hashes a link password
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return "", apperrors.NewValidationError("Password must be between 4 and 72 characters")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", apperrors.WithMessage(err, "failed to hash password")
+	}
+	return string(hash), nil
+}
+
 // CreateShortURL creates a new short URL
-func (s *URLService) CreateShortURL(ctx context.Context, urlStr string, customCode string) (*models.URL, error) {
-	// Validate URL
-	cleanURL, err := s.validateAndSanitizeURL(urlStr)
+func (s *URLService) CreateShortURL(ctx context.Context, req CreateURLRequest) (*models.URL, error) {
+	ctx, span := tracing.Start(ctx, "URLService.CreateShortURL")
+	defer span.End()
+
+	// Validate custom code if provided
+	if req.CustomCode != "" && !isValidCustomCode(req.CustomCode) {
+		return nil, apperrors.NewValidationError("Invalid custom code format")
+	}
+
+	url, err := s.newURL(ctx, req, false)
 	if err != nil {
 		return nil, err
 	}
 
-	// Validate custom code if provided
-	var shortCode string
-	if customCode != "" {
-		if !isValidCustomCode(customCode) {
-			return nil, apperrors.NewValidationError("Invalid custom code format")
+	if req.Password != "" {
+		url.PasswordHash, err = hashPassword(req.Password)
+		if err != nil {
+			return nil, err
 		}
+		url.Protected = true
+	}
 
-		// Check if custom code already exists
-		exists, err := s.repo.CodeExists(ctx, customCode)
+	if url.ShortCode == "" {
+		// Generate a random short code
+		code, err := generateShortCode()
 		if err != nil {
 			return nil, err
 		}
-		if exists {
-			return nil, apperrors.NewValidationError("Custom code already in use")
+		url.ShortCode = code
+	}
+
+	// Check if URL already exists. Only plain links are shared, since reusing
+	// an existing link would drop any per-link settings in the request.
+	plain := len(url.GeoTargets) == 0 && !url.Protected && url.Owner == "" && !url.Interstitial && url.FallbackURL == "" &&
+		len(url.Tags) == 0 && url.Folder == "" && url.Title == "" && url.Description == "" && url.Notes == "" &&
+		url.Card == nil
+	if plain {
+		existingURL, err := s.repo.GetByOriginalURL(ctx, url.Domain, url.OriginalURL)
+		if err != nil {
+			// Only return error if it's not a NotFound error
+			var appErr *apperrors.AppError
+			if errors.As(err, &appErr) && !errors.Is(err, apperrors.ErrNotFound) {
+				return nil, err
+			}
 		}
-		shortCode = customCode
-	} else {
-		// Generate a random short code
-		code, err := generateShortCode()
+
+		// Return existing URL if found
+		if existingURL != nil {
+			return existingURL, nil
+		}
+	}
+
+	// Page metadata is not fetched for protected links, whose destination
+	// must not leak through a public title
+	url.MetadataPending = s.captureMetadata && !url.Protected && HealthTarget(url) != "" &&
+		(url.Title == "" || url.Description == "")
+
+	if err := s.repo.Create(ctx, url); err != nil {
+		return nil, err
+	}
+	metrics.LinksCreated.Inc()
+	publishEvent(ctx, s.events, models.EventLinkCreated, url)
+
+	return url, nil
+}
+
+// newURL validates a create request and builds the URL it describes, without
+// a password. The format of the custom code is checked by the caller; the
+// short code is empty unless the request has one. Imported links are only
+// checked for syntax and against the blocklist: their hosts are not resolved,
+// shortener links are not expanded and short links on this instance are not
+// followed, so large imports stay fast and links to retired hosts still load.
+func (s *URLService) newURL(ctx context.Context, req CreateURLRequest, imported bool) (*models.URL, error) {
+	// Validate URL
+	cleanURL, err := s.validateAndSanitizeURL(ctx, req.URL, !imported)
+	if err != nil {
+		return nil, err
+	}
+
+	geoTargets, err := s.validateGeoTargets(ctx, req.GeoTargets, !imported)
+	if err != nil {
+		return nil, err
+	}
+
+	fallbackURL, err := s.validateFallbackURL(ctx, cleanURL, req.FallbackURL, !imported)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := s.validateDomain(ctx, req.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	// Store the final target of external shortener links, then make sure no
+	// destination leads back to this link through our own short links
+	if !imported {
+		cleanURL, err = s.expandShortener(ctx, cleanURL)
 		if err != nil {
 			return nil, err
 		}
-		shortCode = code
+	}
+	destinations := []string{cleanURL, fallbackURL}
+	for _, target := range geoTargets {
+		destinations = append(destinations, target)
+	}
+	if !imported {
+		for _, destination := range destinations {
+			if err := s.checkRedirectLoop(ctx, destination, domain, req.CustomCode, req.RequestHost); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := s.checkBlocklist(destinations); err != nil {
+		return nil, err
+	}
+
+	owner := strings.TrimSpace(req.Owner)
+	if len(owner) > maxOwnerLength {
+		return nil, apperrors.NewValidationError("Owner is too long (max 100 characters)")
 	}
 
-	// Check if URL already exists
-	existingURL, err := s.repo.GetByOriginalURL(ctx, cleanURL)
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	folder, err := normalizeFolder(req.Folder)
+	if err != nil {
+		return nil, err
+	}
+	title, description, notes, err := validateDetails(req.Title, req.Description, req.Notes)
+	if err != nil {
+		return nil, err
+	}
+	card, err := validateCard(req.Card)
 	if err != nil {
-		// Only return error if it's not a NotFound error
-		var appErr *apperrors.AppError
-		if errors.As(err, &appErr) && !errors.Is(err, apperrors.ErrNotFound) {
+		return nil, err
+	}
+
+	// Check if custom code already exists
+	if req.CustomCode != "" {
+		exists, err := s.repo.CodeExists(ctx, domain, req.CustomCode)
+		if err != nil {
 			return nil, err
 		}
+		if exists {
+			return nil, &apperrors.AppError{
+				Type:    apperrors.ErrorTypeValidation,
+				Message: "Custom code already in use",
+				Err:     ErrCodeConflict,
+			}
+		}
+	}
+
+	return &models.URL{
+		OriginalURL:  cleanURL,
+		ShortCode:    req.CustomCode,
+		CreatedAt:    time.Now(),
+		GeoTargets:   geoTargets,
+		Owner:        owner,
+		Interstitial: req.Interstitial,
+		FallbackURL:  fallbackURL,
+		Domain:       domain,
+		Tags:         tags,
+		Folder:       folder,
+		Title:        title,
+		Description:  description,
+		Notes:        notes,
+		Card:         card,
+	}, nil
+}
+
+// ListURLs retrieves up to limit URLs with IDs above afterID, including
+// disabled ones, in ID order
+func (s *URLService) ListURLs(ctx context.Context, afterID int64, limit int) ([]*models.URL, error) {
+	ctx, span := tracing.Start(ctx, "URLService.ListURLs")
+	defer span.End()
+
+	if limit <= 0 {
+		return nil, apperrors.NewValidationError("limit must be positive")
 	}
+	return s.repo.ListURLs(ctx, afterID, limit)
+}
 
-	// Return existing URL if found
-	if existingURL != nil {
-		return existingURL, nil
+// DeleteURL deletes a short URL along with its visits and reports
+func (s *URLService) DeleteURL(ctx context.Context, domain, code string) error {
+	ctx, span := tracing.Start(ctx, "URLService.DeleteURL")
+	defer span.End()
+
+	url, err := s.GetURL(ctx, domain, code)
+	if err != nil {
+		return err
 	}
+	if err := s.repo.DeleteURL(ctx, url.ID); err != nil {
+		return err
+	}
+	publishEvent(ctx, s.events, models.EventLinkDeleted, url)
+	return nil
+}
+
+// expirePageSize is the number of old URLs loaded per query when each
+// expired link is published as an event
+const expirePageSize = 100
+
+// ExpireOldURLs deletes the URLs older than maxAge, except exempt ones, and
+// returns how many were deleted. When link.expired has subscribers, the URLs
+// are deleted one at a time and each is published.
+func (s *URLService) ExpireOldURLs(ctx context.Context, maxAge time.Duration) (int64, error) {
+	ctx, span := tracing.Start(ctx, "URLService.ExpireOldURLs")
+	defer span.End()
 
-	// Create new short URL
-	url := &models.URL{
-		OriginalURL: cleanURL,
-		ShortCode:   shortCode,
-		CreatedAt:   time.Now(),
+	if s.events == nil || !s.events.Subscribed(ctx, models.EventLinkExpired) {
+		return s.repo.DeleteOldURLs(ctx, maxAge)
 	}
 
-	if err := s.repo.Create(ctx, url); err != nil {
-		return nil, err
+	var deleted int64
+	for {
+		urls, err := s.repo.ListOldURLs(ctx, maxAge, expirePageSize)
+		if err != nil {
+			return deleted, err
+		}
+		for _, url := range urls {
+			if err := s.repo.DeleteURL(ctx, url.ID); err != nil {
+				return deleted, err
+			}
+			deleted++
+			publishEvent(ctx, s.events, models.EventLinkExpired, url)
+		}
+		if len(urls) < expirePageSize {
+			return deleted, nil
+		}
 	}
+}
 
-	return url, nil
-}
\ No newline at end of file
+// CountExpiredURLs counts the URLs that ExpireOldURLs would delete
+func (s *URLService) CountExpiredURLs(ctx context.Context, maxAge time.Duration) (int64, error) {
+	ctx, span := tracing.Start(ctx, "URLService.CountExpiredURLs")
+	defer span.End()
+
+	return s.repo.CountOldURLs(ctx, maxAge)
+}
+
+// DisableURL disables a short URL so that it no longer redirects
+func (s *URLService) DisableURL(ctx context.Context, domain, code, reason string) error {
+	ctx, span := tracing.Start(ctx, "URLService.DisableURL")
+	defer span.End()
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return apperrors.NewValidationError("A reason is required to disable a link")
+	}
+
+	url, err := s.GetURL(ctx, domain, code)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DisableURL(ctx, url.ID, reason); err != nil {
+		return err
+	}
+	url.Disabled, url.DisabledReason = true, reason
+	publishEvent(ctx, s.events, models.EventLinkDisabled, url)
+	return nil
+}
+
+// EnableURL re-enables a disabled short URL
+func (s *URLService) EnableURL(ctx context.Context, domain, code string) error {
+	ctx, span := tracing.Start(ctx, "URLService.EnableURL")
+	defer span.End()
+
+	url, err := s.GetURL(ctx, domain, code)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.EnableURL(ctx, url.ID); err != nil {
+		return err
+	}
+	url.Disabled, url.DisabledReason = false, ""
+	publishEvent(ctx, s.events, models.EventLinkEnabled, url)
+	return nil
+}
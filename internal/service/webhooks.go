@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "github.com/nijaru/nano-link/internal/errors"
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/repository"
+	"github.com/nijaru/nano-link/internal/tracing"
+)
+
+const (
+	// webhookSecretPrefix starts every generated webhook secret
+	webhookSecretPrefix = "whsec_"
+
+	// webhookSecretBytes is the amount of randomness in a generated secret
+	webhookSecretBytes = 24
+
+	// minWebhookSecretLength bounds how short a chosen secret can be
+	minWebhookSecretLength = 16
+
+	// maxWebhookURLLength bounds the length of a webhook URL
+	maxWebhookURLLength = 2048
+
+	// maxDeliveryListLimit bounds a listing of deliveries
+	maxDeliveryListLimit = 500
+)
+
+// WebhookService provides business logic for webhook subscriptions
+type WebhookService struct {
+	repo        repository.WebhookRepository
+	subscribers *webhookCache
+}
+
+// webhookCache holds the webhooks so that publishing an event, which happens
+// on every click, does not query the database
+type webhookCache struct {
+	mu       sync.Mutex
+	webhooks []*models.Webhook // nil until loaded
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(repo repository.WebhookRepository) WebhookService {
+	return WebhookService{repo: repo, subscribers: &webhookCache{}}
+}
+
+// CreateWebhookRequest represents a request to subscribe to link events
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"` // generated if empty
+}
+
+// CreateWebhook stores a webhook and returns it with its secret, which is
+// not shown again
+func (s *WebhookService) CreateWebhook(ctx context.Context, req CreateWebhookRequest) (*models.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.CreateWebhook")
+	defer span.End()
+
+	target := strings.TrimSpace(req.URL)
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, apperrors.NewValidationError("Webhook URL must be an http or https URL")
+	}
+	if len(target) > maxWebhookURLLength {
+		return nil, apperrors.NewValidationError("Webhook URL is too long (max 2048 characters)")
+	}
+
+	events, err := normalizeEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := strings.TrimSpace(req.Secret)
+	if secret == "" {
+		random := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(random); err != nil {
+			return nil, apperrors.WithMessage(err, "failed to generate webhook secret")
+		}
+		secret = webhookSecretPrefix + hex.EncodeToString(random)
+	} else if len(secret) < minWebhookSecretLength {
+		return nil, apperrors.NewValidationError("Webhook secret must be at least 16 characters")
+	}
+
+	webhook := &models.Webhook{URL: target, Events: events, Secret: secret}
+	if err := s.repo.CreateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+	s.subscribers.invalidate()
+	return webhook, nil
+}
+
+// ListWebhooks retrieves all webhooks, without their secrets
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.ListWebhooks")
+	defer span.End()
+
+	webhooks, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook deletes a webhook along with its deliveries
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "WebhookService.DeleteWebhook")
+	defer span.End()
+
+	if err := s.repo.DeleteWebhook(ctx, id); err != nil {
+		return err
+	}
+	s.subscribers.invalidate()
+	return nil
+}
+
+// ListDeliveries retrieves the newest deliveries matching a filter. Listing
+// the deliveries of a webhook that does not exist is an error.
+func (s *WebhookService) ListDeliveries(ctx context.Context, filter repository.DeliveryFilter) ([]*models.WebhookDelivery, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.ListDeliveries")
+	defer span.End()
+
+	switch filter.Status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead:
+	default:
+		return nil, apperrors.NewValidationError("Status must be pending, delivered or dead")
+	}
+	if filter.Limit < 0 || filter.Limit > maxDeliveryListLimit {
+		return nil, apperrors.NewValidationError("Limit must be between 1 and 500")
+	}
+	if filter.WebhookID != 0 {
+		if _, err := s.repo.GetWebhook(ctx, filter.WebhookID); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.ListDeliveries(ctx, filter)
+}
+
+// RetryDelivery queues a delivered or dead delivery to be sent again now,
+// with a fresh set of attempts
+func (s *WebhookService) RetryDelivery(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "WebhookService.RetryDelivery")
+	defer span.End()
+
+	return s.repo.RetryDelivery(ctx, id)
+}
+
+// Subscribers retrieves the webhooks that receive an event
+func (s *WebhookService) Subscribers(ctx context.Context, event string) ([]*models.Webhook, error) {
+	webhooks, err := s.subscribers.load(ctx, s.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var subscribed []*models.Webhook
+	for _, webhook := range webhooks {
+		if webhook.Subscribed(event) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	return subscribed, nil
+}
+
+// Enqueue queues an event for delivery to the webhooks subscribed to it
+func (s *WebhookService) Enqueue(ctx context.Context, event *models.LinkEvent) error {
+	ctx, span := tracing.Start(ctx, "WebhookService.Enqueue")
+	defer span.End()
+
+	webhooks, err := s.Subscribers(ctx, event.Event)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return apperrors.WithMessage(err, "failed to encode event")
+	}
+	ids := make([]int64, len(webhooks))
+	for i, webhook := range webhooks {
+		ids[i] = webhook.ID
+	}
+	return s.repo.EnqueueDeliveries(ctx, ids, event.Event, string(payload))
+}
+
+// WebhookSignature signs a delivery sent at timestamp. Receivers recompute
+// it from the X-Nano-Link-Signature timestamp and the raw request body.
+func WebhookSignature(secret string, timestamp time.Time, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp.Unix())
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// normalizeEvents validates and deduplicates event names, in the order of
+// models.LinkEvents
+func normalizeEvents(events []string) ([]string, error) {
+	requested := make(map[string]bool, len(events))
+	for _, event := range events {
+		requested[strings.ToLower(strings.TrimSpace(event))] = true
+	}
+
+	var normalized []string
+	for _, event := range models.LinkEvents {
+		if requested[event] {
+			normalized = append(normalized, event)
+			delete(requested, event)
+		}
+	}
+	if len(requested) > 0 {
+		return nil, apperrors.NewValidationError("Events must be among " + strings.Join(models.LinkEvents, ", "))
+	}
+	if len(normalized) == 0 {
+		return nil, apperrors.NewValidationError("At least one event is required")
+	}
+	return normalized, nil
+}
+
+// load returns the cached webhooks, reading them on first use
+func (c *webhookCache) load(ctx context.Context, repo repository.WebhookRepository) ([]*models.Webhook, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.webhooks == nil {
+		webhooks, err := repo.ListWebhooks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.webhooks = webhooks
+	}
+	return c.webhooks, nil
+}
+
+// invalidate makes the next load read the webhooks again
+func (c *webhookCache) invalidate() {
+	c.mu.Lock()
+	c.webhooks = nil
+	c.mu.Unlock()
+}
@@ -0,0 +1,124 @@
+package tasks
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nijaru/nano-link/internal/blocklist"
+	customLogger "github.com/nijaru/nano-link/internal/logger"
+)
+
+// BlocklistChecker disables existing links matching the blocklist
+type BlocklistChecker interface {
+	RecheckBlocklist(ctx context.Context) (int64, error)
+}
+
+// BlocklistTask reloads the blocklist on SIGHUP or when its files change and
+// re-checks existing links after every reload
+type BlocklistTask struct {
+	list       *blocklist.Blocklist
+	checker    BlocklistChecker
+	interval   time.Duration
+	ticker     *time.Ticker
+	signals    chan os.Signal
+	cancelFunc context.CancelFunc
+	wg         sync.WaitGroup
+	ctx        context.Context
+	heartbeat  *Heartbeat
+}
+
+// NewBlocklistTask creates a new blocklist task that polls the files for
+// changes every interval
+func NewBlocklistTask(list *blocklist.Blocklist, checker BlocklistChecker, interval time.Duration) *BlocklistTask {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &BlocklistTask{
+		list:       list,
+		checker:    checker,
+		interval:   interval,
+		ticker:     time.NewTicker(interval),
+		signals:    make(chan os.Signal, 1),
+		cancelFunc: cancel,
+		ctx:        ctx,
+		heartbeat:  newHeartbeat("blocklist", interval),
+	}
+}
+
+// Start begins watching the blocklist. Existing links are checked once at
+// startup, since the files may have changed while the server was down.
+func (t *BlocklistTask) Start() {
+	signal.Notify(t.signals, syscall.SIGHUP)
+
+	t.heartbeat.start()
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer t.heartbeat.stop()
+		t.runRecheck()
+		for {
+			select {
+			case <-t.signals:
+				customLogger.Info("Received SIGHUP, reloading blocklist")
+				t.reload()
+				t.heartbeat.beat()
+			case <-t.ticker.C:
+				if t.list.Changed() {
+					t.reload()
+				}
+				t.heartbeat.beat()
+			case <-t.ctx.Done():
+				customLogger.Info("Blocklist task shutdown")
+				return
+			}
+		}
+	}()
+	customLogger.Info("Blocklist task started", map[string]interface{}{
+		"patterns": t.list.Size(),
+		"interval": t.interval.String(),
+	})
+}
+
+// Stop gracefully stops the blocklist task
+func (t *BlocklistTask) Stop() {
+	signal.Stop(t.signals)
+	t.ticker.Stop()
+	t.cancelFunc()
+	t.wg.Wait()
+}
+
+// Heartbeat returns the heartbeat of the task loop
+func (t *BlocklistTask) Heartbeat() *Heartbeat {
+	return t.heartbeat
+}
+
+// reload re-reads the blocklist files, keeping the old patterns on error
+func (t *BlocklistTask) reload() {
+	if err := t.list.Reload(); err != nil {
+		customLogger.Error(err, "Failed to reload blocklist, keeping previous patterns")
+		return
+	}
+	customLogger.Info("Blocklist reloaded", map[string]interface{}{
+		"patterns": t.list.Size(),
+	})
+	t.runRecheck()
+}
+
+// runRecheck disables existing links that match the blocklist
+func (t *BlocklistTask) runRecheck() {
+	ctx, cancel := context.WithTimeout(t.ctx, 5*time.Minute)
+	defer cancel()
+
+	disabled, err := t.checker.RecheckBlocklist(ctx)
+	if err != nil {
+		customLogger.Error(err, "Failed to re-check links against blocklist")
+		return
+	}
+	if disabled > 0 {
+		customLogger.Info("Disabled blocklisted links", map[string]interface{}{
+			"disabled_count": disabled,
+		})
+	}
+}
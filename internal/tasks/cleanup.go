@@ -6,42 +6,53 @@ import (
 	"time"
 
 	customLogger "github.com/nijaru/nano-link/internal/logger"
+	"github.com/nijaru/nano-link/internal/metrics"
 	"github.com/nijaru/nano-link/internal/repository"
 )
 
+// URLExpirer deletes URLs that have outlived the maximum age
+type URLExpirer interface {
+	ExpireOldURLs(ctx context.Context, maxAge time.Duration) (int64, error)
+}
+
 // CleanupTask represents a background task for cleaning up old URLs
 type CleanupTask struct {
-	repo       repository.URLRepository
+	expirer    URLExpirer
 	interval   time.Duration
 	maxAge     time.Duration
 	ticker     *time.Ticker
 	cancelFunc context.CancelFunc
 	wg         sync.WaitGroup
 	ctx        context.Context
+	heartbeat  *Heartbeat
 }
 
 // NewCleanupTask creates a new cleanup task
-func NewCleanupTask(repo repository.URLRepository, interval, maxAge time.Duration) *CleanupTask {
+func NewCleanupTask(expirer URLExpirer, interval, maxAge time.Duration) *CleanupTask {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &CleanupTask{
-		repo:       repo,
+		expirer:    expirer,
 		interval:   interval,
 		maxAge:     maxAge,
 		ticker:     time.NewTicker(interval),
 		cancelFunc: cancel,
 		ctx:        ctx,
+		heartbeat:  newHeartbeat("cleanup", interval),
 	}
 }
 
 // Start begins the cleanup task
 func (t *CleanupTask) Start() {
+	t.heartbeat.start()
 	t.wg.Add(1)
 	go func() {
 		defer t.wg.Done()
+		defer t.heartbeat.stop()
 		for {
 			select {
 			case <-t.ticker.C:
 				t.runCleanup()
+				t.heartbeat.beat()
 			case <-t.ctx.Done():
 				customLogger.Info("Cleanup task shutdown")
 				return
@@ -61,6 +72,11 @@ func (t *CleanupTask) Stop() {
 	t.wg.Wait()
 }
 
+// Heartbeat returns the heartbeat of the task loop
+func (t *CleanupTask) Heartbeat() *Heartbeat {
+	return t.heartbeat
+}
+
 // runCleanup performs the actual cleanup operation
 func (t *CleanupTask) runCleanup() {
 	// Create a context with timeout for the cleanup operation
@@ -68,11 +84,14 @@ func (t *CleanupTask) runCleanup() {
 	defer cancel()
 
 	// Perform the cleanup
-	deleted, err := t.repo.DeleteOldURLs(ctx, t.maxAge)
+	start := time.Now()
+	deleted, err := t.expirer.ExpireOldURLs(ctx, t.maxAge)
+	metrics.CleanupDuration.Observe(time.Since(start).Seconds())
 	if err != nil {
 		customLogger.Error(err, "Failed to cleanup old URLs")
 		return
 	}
+	metrics.CleanupDeleted.Add(float64(deleted))
 
 	// Log the result
 	customLogger.Info("Cleanup task completed", map[string]interface{}{
@@ -80,9 +99,19 @@ func (t *CleanupTask) runCleanup() {
 	})
 }
 
+// repositoryExpirer deletes old URLs straight from the repository, without
+// publishing events
+type repositoryExpirer struct {
+	repo repository.URLRepository
+}
+
+func (e repositoryExpirer) ExpireOldURLs(ctx context.Context, maxAge time.Duration) (int64, error) {
+	return e.repo.DeleteOldURLs(ctx, maxAge)
+}
+
 // StartCleanupTask starts a new cleanup task (legacy wrapper)
 func StartCleanupTask(repo repository.URLRepository, interval, maxAge time.Duration) *CleanupTask {
-	task := NewCleanupTask(repo, interval, maxAge)
+	task := NewCleanupTask(repositoryExpirer{repo: repo}, interval, maxAge)
 	task.Start()
 	return task
 }
@@ -0,0 +1,278 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	customLogger "github.com/nijaru/nano-link/internal/logger"
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/repository"
+	"github.com/nijaru/nano-link/internal/service"
+)
+
+const (
+	// healthPageSize is the number of links loaded per query during a run
+	healthPageSize = 500
+
+	// webhookTimeout bounds each webhook delivery
+	webhookTimeout = 10 * time.Second
+)
+
+// DestinationChecker checks and records the health of link destinations
+type DestinationChecker interface {
+	CheckDestination(ctx context.Context, url *models.URL) *models.LinkHealth
+	RecordHealth(ctx context.Context, url *models.URL, health *models.LinkHealth) error
+}
+
+// HealthCheckConfig controls the destination health checker
+type HealthCheckConfig struct {
+	Interval    time.Duration // time between runs
+	Concurrency int           // checks in flight at once
+	HostDelay   time.Duration // minimum time between requests to one host
+	WebhookURL  string        // notified when a link starts failing; optional
+}
+
+// HealthCheckTask periodically checks that link destinations still respond
+type HealthCheckTask struct {
+	repo       repository.URLRepository
+	checker    DestinationChecker
+	cfg        HealthCheckConfig
+	webhook    *http.Client
+	ticker     *time.Ticker
+	cancelFunc context.CancelFunc
+	wg         sync.WaitGroup
+	ctx        context.Context
+	heartbeat  *Heartbeat
+
+	hostsMu sync.Mutex
+	hosts   map[string]*hostGate
+}
+
+// hostGate spaces out requests to a single host
+type hostGate struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewHealthCheckTask creates a new destination health check task
+func NewHealthCheckTask(repo repository.URLRepository, checker DestinationChecker, cfg HealthCheckConfig) *HealthCheckTask {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HealthCheckTask{
+		repo:       repo,
+		checker:    checker,
+		cfg:        cfg,
+		webhook:    &http.Client{Timeout: webhookTimeout},
+		ticker:     time.NewTicker(cfg.Interval),
+		cancelFunc: cancel,
+		ctx:        ctx,
+		heartbeat:  newHeartbeat("health check", cfg.Interval),
+		hosts:      make(map[string]*hostGate),
+	}
+}
+
+// Start begins the health check task
+func (t *HealthCheckTask) Start() {
+	t.heartbeat.start()
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer t.heartbeat.stop()
+		for {
+			select {
+			case <-t.ticker.C:
+				t.runChecks()
+				t.heartbeat.beat()
+			case <-t.ctx.Done():
+				customLogger.Info("Health check task shutdown")
+				return
+			}
+		}
+	}()
+	customLogger.Info("Health check task started", map[string]interface{}{
+		"interval":    t.cfg.Interval.String(),
+		"concurrency": t.cfg.Concurrency,
+		"host_delay":  t.cfg.HostDelay.String(),
+	})
+}
+
+// Stop gracefully stops the health check task, abandoning a run in progress
+func (t *HealthCheckTask) Stop() {
+	t.ticker.Stop()
+	t.cancelFunc()
+	t.wg.Wait()
+}
+
+// Heartbeat returns the heartbeat of the task loop
+func (t *HealthCheckTask) Heartbeat() *Heartbeat {
+	return t.heartbeat
+}
+
+// runChecks checks every enabled link with a web destination
+func (t *HealthCheckTask) runChecks() {
+	start := time.Now()
+	jobs := make(chan *models.URL)
+
+	t.hostsMu.Lock()
+	t.hosts = make(map[string]*hostGate)
+	t.hostsMu.Unlock()
+
+	var checked, broken int64
+	var countMu sync.Mutex
+
+	var workers sync.WaitGroup
+	for i := 0; i < t.cfg.Concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for link := range jobs {
+				failed := t.checkLink(link)
+				t.heartbeat.beat()
+				countMu.Lock()
+				checked++
+				if failed {
+					broken++
+				}
+				countMu.Unlock()
+			}
+		}()
+	}
+
+	var afterID int64
+	for t.ctx.Err() == nil {
+		links, err := t.repo.ListEnabledURLs(t.ctx, afterID, healthPageSize)
+		if err != nil {
+			customLogger.Error(err, "Failed to list URLs for health checks")
+			break
+		}
+		for _, link := range links {
+			if service.HealthTarget(link) == "" {
+				continue
+			}
+			select {
+			case jobs <- link:
+			case <-t.ctx.Done():
+			}
+		}
+		if len(links) < healthPageSize {
+			break
+		}
+		afterID = links[len(links)-1].ID
+	}
+	close(jobs)
+	workers.Wait()
+
+	customLogger.Info("Health check task completed", map[string]interface{}{
+		"checked_count": checked,
+		"broken_count":  broken,
+		"duration":      time.Since(start).String(),
+	})
+}
+
+// checkLink checks one link, records the result and reports whether it is broken
+func (t *HealthCheckTask) checkLink(link *models.URL) bool {
+	if t.ctx.Err() != nil {
+		return false
+	}
+
+	t.waitForHost(service.HealthTarget(link), func() {
+		health := t.checker.CheckDestination(t.ctx, link)
+		if t.ctx.Err() != nil {
+			return
+		}
+
+		if err := t.checker.RecordHealth(t.ctx, link, health); err != nil {
+			customLogger.Error(err, "Failed to record link health", map[string]interface{}{"code": link.ShortCode})
+		}
+		if health.Broken() && !link.Health.Broken() {
+			t.notifyBroken(link, health)
+		}
+		link.Health = health
+	})
+	return link.Health.Broken()
+}
+
+// waitForHost runs fn once at least HostDelay has passed since the previous
+// request to the same host
+func (t *HealthCheckTask) waitForHost(target string, fn func()) {
+	host := target
+	if u, err := url.Parse(target); err == nil {
+		host = u.Hostname()
+	}
+
+	t.hostsMu.Lock()
+	gate, ok := t.hosts[host]
+	if !ok {
+		gate = &hostGate{}
+		t.hosts[host] = gate
+	}
+	t.hostsMu.Unlock()
+
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+
+	if wait := time.Until(gate.last.Add(t.cfg.HostDelay)); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-t.ctx.Done():
+			return
+		}
+	}
+	fn()
+	gate.last = time.Now()
+}
+
+// healthWebhookPayload is posted to the webhook when a link starts failing
+type healthWebhookPayload struct {
+	Event       string             `json:"event"`
+	ShortCode   string             `json:"short_code"`
+	Domain      string             `json:"domain,omitempty"`
+	Destination string             `json:"destination"`
+	Health      *models.LinkHealth `json:"health"`
+}
+
+// notifyBroken posts a link that started failing to the webhook
+func (t *HealthCheckTask) notifyBroken(link *models.URL, health *models.LinkHealth) {
+	if t.cfg.WebhookURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(healthWebhookPayload{
+		Event:       "link.broken",
+		ShortCode:   link.ShortCode,
+		Domain:      link.Domain,
+		Destination: service.HealthTarget(link),
+		Health:      health,
+	})
+	if err != nil {
+		customLogger.Error(err, "Failed to encode health webhook")
+		return
+	}
+
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodPost, t.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		customLogger.Error(err, "Failed to create health webhook request")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.webhook.Do(req)
+	if err != nil {
+		customLogger.Error(err, "Failed to deliver health webhook", map[string]interface{}{"code": link.ShortCode})
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		customLogger.Error(fmt.Errorf("webhook returned status %d", resp.StatusCode), "Failed to deliver health webhook", map[string]interface{}{
+			"code": link.ShortCode,
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package tasks
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// heartbeatGrace is added to the allowed silence of every task, covering
+// runs up to the longest task timeout
+const heartbeatGrace = 5 * time.Minute
+
+// Heartbeat records when a background task loop last made progress, so a
+// hung or stopped task can be detected
+type Heartbeat struct {
+	name     string
+	interval time.Duration
+	last     atomic.Int64 // unix nanoseconds of the last beat
+	running  atomic.Bool
+}
+
+// newHeartbeat creates a heartbeat for a task that beats every interval
+func newHeartbeat(name string, interval time.Duration) *Heartbeat {
+	return &Heartbeat{name: name, interval: interval}
+}
+
+// Name returns the name of the task
+func (h *Heartbeat) Name() string {
+	return h.name
+}
+
+// Last returns the time of the last beat
+func (h *Heartbeat) Last() time.Time {
+	return time.Unix(0, h.last.Load())
+}
+
+// Check returns an error if the task loop is not running or has not beaten
+// for more than two intervals
+func (h *Heartbeat) Check() error {
+	if !h.running.Load() {
+		return fmt.Errorf("%s task is not running", h.name)
+	}
+	if since := time.Since(h.Last()); since > 2*h.interval+heartbeatGrace {
+		return fmt.Errorf("%s task last made progress %s ago", h.name, since.Round(time.Second))
+	}
+	return nil
+}
+
+// start marks the task loop as running
+func (h *Heartbeat) start() {
+	h.beat()
+	h.running.Store(true)
+}
+
+// stop marks the task loop as exited
+func (h *Heartbeat) stop() {
+	h.running.Store(false)
+}
+
+// beat records progress
+func (h *Heartbeat) beat() {
+	h.last.Store(time.Now().UnixNano())
+}
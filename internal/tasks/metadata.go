@@ -0,0 +1,149 @@
+package tasks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	customLogger "github.com/nijaru/nano-link/internal/logger"
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/repository"
+	"github.com/nijaru/nano-link/internal/service"
+)
+
+const (
+	// metadataPageSize is the number of pending links loaded per query
+	metadataPageSize = 100
+
+	// metadataConcurrency is the number of pages fetched at once
+	metadataConcurrency = 4
+)
+
+// MetadataFetcher fetches and records the page metadata of links
+type MetadataFetcher interface {
+	FetchMetadata(ctx context.Context, target string) (*service.PageMetadata, error)
+	RecordMetadata(ctx context.Context, url *models.URL, meta *service.PageMetadata) error
+}
+
+// MetadataTask fetches the title and description of newly created links
+// whose destination page has not been read yet
+type MetadataTask struct {
+	repo       repository.URLRepository
+	fetcher    MetadataFetcher
+	interval   time.Duration
+	ticker     *time.Ticker
+	cancelFunc context.CancelFunc
+	wg         sync.WaitGroup
+	ctx        context.Context
+	heartbeat  *Heartbeat
+}
+
+// NewMetadataTask creates a new metadata fetch task
+func NewMetadataTask(repo repository.URLRepository, fetcher MetadataFetcher, interval time.Duration) *MetadataTask {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &MetadataTask{
+		repo:       repo,
+		fetcher:    fetcher,
+		interval:   interval,
+		ticker:     time.NewTicker(interval),
+		cancelFunc: cancel,
+		ctx:        ctx,
+		heartbeat:  newHeartbeat("metadata", interval),
+	}
+}
+
+// Start begins the metadata fetch task
+func (t *MetadataTask) Start() {
+	t.heartbeat.start()
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer t.heartbeat.stop()
+		for {
+			select {
+			case <-t.ticker.C:
+				t.runFetches()
+				t.heartbeat.beat()
+			case <-t.ctx.Done():
+				customLogger.Info("Metadata task shutdown")
+				return
+			}
+		}
+	}()
+	customLogger.Info("Metadata task started", map[string]interface{}{
+		"interval": t.interval.String(),
+	})
+}
+
+// Stop gracefully stops the metadata task, abandoning fetches in progress
+func (t *MetadataTask) Stop() {
+	t.ticker.Stop()
+	t.cancelFunc()
+	t.wg.Wait()
+}
+
+// Heartbeat returns the heartbeat of the task loop
+func (t *MetadataTask) Heartbeat() *Heartbeat {
+	return t.heartbeat
+}
+
+// runFetches fetches the metadata of every pending link
+func (t *MetadataTask) runFetches() {
+	jobs := make(chan *models.URL)
+
+	var workers sync.WaitGroup
+	for i := 0; i < metadataConcurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for link := range jobs {
+				t.fetchLink(link)
+				t.heartbeat.beat()
+			}
+		}()
+	}
+
+	var afterID int64
+	for t.ctx.Err() == nil {
+		links, err := t.repo.ListPendingMetadata(t.ctx, afterID, metadataPageSize)
+		if err != nil {
+			customLogger.Error(err, "Failed to list links pending metadata")
+			break
+		}
+		for _, link := range links {
+			select {
+			case jobs <- link:
+			case <-t.ctx.Done():
+			}
+		}
+		if len(links) < metadataPageSize {
+			break
+		}
+		afterID = links[len(links)-1].ID
+	}
+	close(jobs)
+	workers.Wait()
+}
+
+// fetchLink fetches and records the metadata of one link. A failed fetch is
+// not retried; the link keeps its empty fields.
+func (t *MetadataTask) fetchLink(link *models.URL) {
+	if t.ctx.Err() != nil {
+		return
+	}
+
+	meta, err := t.fetcher.FetchMetadata(t.ctx, service.HealthTarget(link))
+	if t.ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		customLogger.Debug("Failed to fetch page metadata", map[string]interface{}{
+			"code":  link.ShortCode,
+			"error": err.Error(),
+		})
+	}
+
+	if err := t.fetcher.RecordMetadata(t.ctx, link, meta); err != nil {
+		customLogger.Error(err, "Failed to record page metadata", map[string]interface{}{"code": link.ShortCode})
+	}
+}
@@ -0,0 +1,295 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	customLogger "github.com/nijaru/nano-link/internal/logger"
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/repository"
+	"github.com/nijaru/nano-link/internal/service"
+)
+
+const (
+	// deliveryBatchSize is the number of due deliveries loaded per query
+	deliveryBatchSize = 50
+
+	// deliveryConcurrency is the number of deliveries sent at once
+	deliveryConcurrency = 4
+
+	// firstRetryDelay is the wait after the first failed attempt; it doubles
+	// after every further failure up to maxRetryDelay
+	firstRetryDelay = 30 * time.Second
+	maxRetryDelay   = 6 * time.Hour
+
+	// pruneInterval is the time between prunes of old deliveries
+	pruneInterval = time.Hour
+
+	// maxDeliveryErrorLength bounds the stored error of a failed attempt
+	maxDeliveryErrorLength = 500
+
+	// webhookUserAgent identifies webhook deliveries
+	webhookUserAgent = "nano-link-webhooks/1.0"
+)
+
+// WebhookConfig controls webhook deliveries
+type WebhookConfig struct {
+	PollInterval time.Duration // time between checks for due deliveries
+	MaxAttempts  int           // attempts before a delivery is dead
+	Retention    time.Duration // age at which finished deliveries are pruned
+}
+
+// WebhookTask publishes link events to the delivery queue and sends queued
+// deliveries, retrying failures with exponential backoff
+type WebhookTask struct {
+	repo       repository.WebhookRepository
+	webhooks   *service.WebhookService
+	cfg        WebhookConfig
+	client     *http.Client
+	ticker     *time.Ticker
+	cancelFunc context.CancelFunc
+	wg         sync.WaitGroup
+	ctx        context.Context
+	heartbeat  *Heartbeat
+	lastPrune  time.Time
+}
+
+// NewWebhookTask creates a new webhook delivery task. It can publish events
+// without being started, leaving the deliveries to another process.
+func NewWebhookTask(repo repository.WebhookRepository, webhooks *service.WebhookService, cfg WebhookConfig) *WebhookTask {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebhookTask{
+		repo:     repo,
+		webhooks: webhooks,
+		cfg:      cfg,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+			// A redirect is reported as a failure rather than followed
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		ticker:     time.NewTicker(cfg.PollInterval),
+		cancelFunc: cancel,
+		ctx:        ctx,
+		heartbeat:  newHeartbeat("webhooks", cfg.PollInterval),
+	}
+}
+
+// Start begins the webhook delivery task
+func (t *WebhookTask) Start() {
+	t.heartbeat.start()
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer t.heartbeat.stop()
+		for {
+			select {
+			case <-t.ticker.C:
+				t.runDeliveries()
+				t.prune()
+				t.heartbeat.beat()
+			case <-t.ctx.Done():
+				customLogger.Info("Webhook task shutdown")
+				return
+			}
+		}
+	}()
+	customLogger.Info("Webhook task started", map[string]interface{}{
+		"poll_interval": t.cfg.PollInterval.String(),
+		"max_attempts":  t.cfg.MaxAttempts,
+	})
+}
+
+// Stop gracefully stops the webhook task. Deliveries in progress are
+// abandoned and retried after a restart.
+func (t *WebhookTask) Stop() {
+	t.ticker.Stop()
+	t.cancelFunc()
+	t.wg.Wait()
+}
+
+// Heartbeat returns the heartbeat of the task loop
+func (t *WebhookTask) Heartbeat() *Heartbeat {
+	return t.heartbeat
+}
+
+// Publish queues an event for the webhooks subscribed to it
+func (t *WebhookTask) Publish(ctx context.Context, event *models.LinkEvent) {
+	if err := t.webhooks.Enqueue(ctx, event); err != nil {
+		customLogger.ErrorContext(ctx, err, "Failed to queue webhook deliveries", map[string]interface{}{
+			"event": event.Event,
+		})
+	}
+}
+
+// Subscribed reports whether any webhook receives an event
+func (t *WebhookTask) Subscribed(ctx context.Context, event string) bool {
+	webhooks, err := t.webhooks.Subscribers(ctx, event)
+	if err != nil {
+		customLogger.ErrorContext(ctx, err, "Failed to load webhooks", map[string]interface{}{"event": event})
+		return false
+	}
+	return len(webhooks) > 0
+}
+
+// runDeliveries sends every due delivery
+func (t *WebhookTask) runDeliveries() {
+	webhooks, err := t.repo.ListWebhooks(t.ctx)
+	if err != nil {
+		customLogger.Error(err, "Failed to load webhooks")
+		return
+	}
+	byID := make(map[int64]*models.Webhook, len(webhooks))
+	for _, webhook := range webhooks {
+		byID[webhook.ID] = webhook
+	}
+
+	for t.ctx.Err() == nil {
+		deliveries, err := t.repo.ListDueDeliveries(t.ctx, time.Now(), deliveryBatchSize)
+		if err != nil {
+			customLogger.Error(err, "Failed to list due webhook deliveries")
+			return
+		}
+
+		// Each batch is finished before the next is loaded, so a delivery
+		// is never sent twice at once
+		jobs := make(chan *models.WebhookDelivery)
+		var workers sync.WaitGroup
+		for i := 0; i < deliveryConcurrency; i++ {
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				for delivery := range jobs {
+					t.deliver(byID[delivery.WebhookID], delivery)
+					t.heartbeat.beat()
+				}
+			}()
+		}
+		for _, delivery := range deliveries {
+			select {
+			case jobs <- delivery:
+			case <-t.ctx.Done():
+			}
+		}
+		close(jobs)
+		workers.Wait()
+
+		if len(deliveries) < deliveryBatchSize {
+			return
+		}
+	}
+}
+
+// deliver sends one delivery and records the outcome
+func (t *WebhookTask) deliver(webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	if t.ctx.Err() != nil {
+		return
+	}
+
+	statusCode, err := t.send(webhook, delivery)
+	if t.ctx.Err() != nil {
+		return
+	}
+	if err == nil {
+		if err := t.repo.RecordDeliverySuccess(t.ctx, delivery.ID, statusCode); err != nil {
+			customLogger.Error(err, "Failed to record webhook delivery", map[string]interface{}{"delivery_id": delivery.ID})
+		}
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	dead := attempts >= t.cfg.MaxAttempts
+	message := err.Error()
+	if len(message) > maxDeliveryErrorLength {
+		message = message[:maxDeliveryErrorLength]
+	}
+	if err := t.repo.RecordDeliveryFailure(t.ctx, delivery.ID, statusCode, message, time.Now().Add(retryDelay(attempts)), dead); err != nil {
+		customLogger.Error(err, "Failed to record webhook delivery", map[string]interface{}{"delivery_id": delivery.ID})
+		return
+	}
+
+	fields := map[string]interface{}{
+		"delivery_id": delivery.ID,
+		"webhook_id":  delivery.WebhookID,
+		"event":       delivery.Event,
+		"attempts":    attempts,
+	}
+	if dead {
+		customLogger.Error(err, "Webhook delivery failed permanently", fields)
+	} else {
+		fields["error"] = message
+		customLogger.Debug("Webhook delivery failed, will retry", fields)
+	}
+}
+
+// send posts a delivery to its webhook and returns the response status.
+// Responses other than 2xx are errors.
+func (t *WebhookTask) send(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	if webhook == nil {
+		return 0, fmt.Errorf("webhook %d no longer exists", delivery.WebhookID)
+	}
+
+	ctx, cancel := context.WithTimeout(t.ctx, webhookTimeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set("X-Nano-Link-Event", delivery.Event)
+	req.Header.Set("X-Nano-Link-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set("X-Nano-Link-Signature", fmt.Sprintf("t=%d,v1=%s", now.Unix(), service.WebhookSignature(webhook.Secret, now, body)))
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// prune deletes old finished deliveries, at most once per pruneInterval
+func (t *WebhookTask) prune() {
+	if t.cfg.Retention <= 0 || time.Since(t.lastPrune) < pruneInterval {
+		return
+	}
+	t.lastPrune = time.Now()
+
+	deleted, err := t.repo.PruneDeliveries(t.ctx, t.cfg.Retention)
+	if err != nil {
+		customLogger.Error(err, "Failed to prune webhook deliveries")
+		return
+	}
+	if deleted > 0 {
+		customLogger.Info("Pruned webhook deliveries", map[string]interface{}{"deleted_count": deleted})
+	}
+}
+
+// retryDelay returns the wait before the next attempt after a number of
+// failed attempts
+func retryDelay(attempts int) time.Duration {
+	delay := firstRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// The methods below call the admin endpoints and need WithToken
+
+// ListReports retrieves the newest abuse reports with a status, or all
+// reports if status is empty
+func (c *Client) ListReports(ctx context.Context, status string, limit int) ([]*Report, error) {
+	query := url.Values{}
+	setQuery(query, "status", status)
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var reports []*Report
+	if err := c.do(ctx, http.MethodGet, "/api/admin/reports", query, nil, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// DismissReport closes an abuse report as unfounded
+func (c *Client) DismissReport(ctx context.Context, id int64) (*Report, error) {
+	return c.resolveReport(ctx, id, "dismiss")
+}
+
+// ConfirmReport confirms an abuse report and disables the reported link
+func (c *Client) ConfirmReport(ctx context.Context, id int64) (*Report, error) {
+	return c.resolveReport(ctx, id, "confirm")
+}
+
+// resolveReport dismisses or confirms a report
+func (c *Client) resolveReport(ctx context.Context, id int64, action string) (*Report, error) {
+	var report Report
+	path := codePath("/api/admin/reports", strconv.FormatInt(id, 10), action)
+	if err := c.do(ctx, http.MethodPost, path, nil, nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// CreateWebhook subscribes a URL to link events. The returned webhook holds
+// the signing secret, which is not shown again.
+func (c *Client) CreateWebhook(ctx context.Context, req CreateWebhookRequest) (*Webhook, error) {
+	var webhook Webhook
+	if err := c.do(ctx, http.MethodPost, "/api/admin/webhooks", nil, req, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks retrieves every webhook, without secrets
+func (c *Client) ListWebhooks(ctx context.Context) ([]*Webhook, error) {
+	var webhooks []*Webhook
+	if err := c.do(ctx, http.MethodGet, "/api/admin/webhooks", nil, nil, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook deletes a webhook and its deliveries
+func (c *Client) DeleteWebhook(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, codePath("/api/admin/webhooks", strconv.FormatInt(id, 10)), nil, nil, nil)
+}
+
+// ListDeliveries retrieves the newest deliveries of every webhook
+func (c *Client) ListDeliveries(ctx context.Context, opts ListDeliveriesOptions) ([]*WebhookDelivery, error) {
+	return c.listDeliveries(ctx, "/api/admin/webhooks/deliveries", opts)
+}
+
+// ListWebhookDeliveries retrieves the newest deliveries of a webhook
+func (c *Client) ListWebhookDeliveries(ctx context.Context, webhookID int64, opts ListDeliveriesOptions) ([]*WebhookDelivery, error) {
+	return c.listDeliveries(ctx, codePath("/api/admin/webhooks", strconv.FormatInt(webhookID, 10), "deliveries"), opts)
+}
+
+// RetryDelivery queues a delivered or dead delivery to be sent again
+func (c *Client) RetryDelivery(ctx context.Context, id int64) error {
+	path := codePath("/api/admin/webhooks/deliveries", strconv.FormatInt(id, 10), "retry")
+	return c.do(ctx, http.MethodPost, path, nil, nil, nil)
+}
+
+// listDeliveries retrieves deliveries from one of the listing endpoints
+func (c *Client) listDeliveries(ctx context.Context, path string, opts ListDeliveriesOptions) ([]*WebhookDelivery, error) {
+	query := url.Values{}
+	setQuery(query, "status", opts.Status)
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+
+	var deliveries []*WebhookDelivery
+	if err := c.do(ctx, http.MethodGet, path, query, nil, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,291 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// CreateURL creates a short URL. A plain link to a destination that is
+// already shortened returns the existing short URL.
+func (c *Client) CreateURL(ctx context.Context, req CreateURLRequest) (*URLResponse, error) {
+	var resp URLResponse
+	if err := c.do(ctx, http.MethodPost, "/api/shorten", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetURL retrieves a short URL
+func (c *Client) GetURL(ctx context.Context, code string) (*URLResponse, error) {
+	var resp URLResponse
+	if err := c.do(ctx, http.MethodGet, codePath("/api/urls", code), c.codeQuery(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateURL changes the title, description, notes or social card of a short URL
+func (c *Client) UpdateURL(ctx context.Context, code string, req UpdateURLRequest) (*URLResponse, error) {
+	var resp URLResponse
+	if err := c.do(ctx, http.MethodPatch, codePath("/api/urls", code), c.codeQuery(), req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListURLs retrieves a page of short URLs
+func (c *Client) ListURLs(ctx context.Context, opts ListURLsOptions) (*URLPage, error) {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	setQuery(query, "sort", opts.Sort)
+	if opts.Ascending {
+		query.Set("order", "asc")
+	}
+	setQuery(query, "cursor", opts.Cursor)
+	setQuery(query, "destination", opts.Destination)
+	setQuery(query, "q", opts.Query)
+	setQuery(query, "owner", opts.Owner)
+	setQuery(query, "status", opts.Status)
+	setQuery(query, "health", opts.Health)
+	setQuery(query, "tag", opts.Tag)
+	setQuery(query, "folder", opts.Folder)
+	if !opts.CreatedAfter.IsZero() {
+		query.Set("created_after", opts.CreatedAfter.Format(time.RFC3339))
+	}
+	if !opts.CreatedBefore.IsZero() {
+		query.Set("created_before", opts.CreatedBefore.Format(time.RFC3339))
+	}
+
+	var page URLPage
+	if err := c.do(ctx, http.MethodGet, "/api/urls", query, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// GetCountryVisits retrieves the visits of a short URL by country, most
+// visits first
+func (c *Client) GetCountryVisits(ctx context.Context, code string) ([]*CountryVisits, error) {
+	var resp struct {
+		Countries []*CountryVisits `json:"countries"`
+	}
+	if err := c.do(ctx, http.MethodGet, codePath("/api/urls", code, "countries"), c.codeQuery(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Countries, nil
+}
+
+// GetPreview retrieves the details shown on the preview page of a short URL
+func (c *Client) GetPreview(ctx context.Context, code string) (*Preview, error) {
+	var preview Preview
+	if err := c.do(ctx, http.MethodGet, codePath("/api/urls", code, "preview"), c.codeQuery(), nil, &preview); err != nil {
+		return nil, err
+	}
+	return &preview, nil
+}
+
+// GetQRCode retrieves a QR code image of a short URL and its content type
+func (c *Client) GetQRCode(ctx context.Context, code string, opts QROptions) ([]byte, string, error) {
+	query := c.codeQuery()
+	setQuery(query, "format", opts.Format)
+	if opts.Size > 0 {
+		query.Set("size", strconv.Itoa(opts.Size))
+	}
+	if opts.Margin != nil {
+		query.Set("margin", strconv.Itoa(*opts.Margin))
+	}
+	setQuery(query, "level", opts.Level)
+	setQuery(query, "fg", opts.Foreground)
+	setQuery(query, "bg", opts.Background)
+	if opts.Logo {
+		query.Set("logo", "true")
+	}
+
+	resp, err := c.send(ctx, http.MethodGet, codePath("/api/urls", code, "qr"), query, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	image, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return image, resp.Header.Get("Content-Type"), nil
+}
+
+// GetStats retrieves usage statistics
+func (c *Client) GetStats(ctx context.Context) (*Stats, error) {
+	var stats Stats
+	if err := c.do(ctx, http.MethodGet, "/api/stats", nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// AddTags adds tags to a short URL
+func (c *Client) AddTags(ctx context.Context, code string, tags ...string) (*URLResponse, error) {
+	body := struct {
+		Tags []string `json:"tags"`
+	}{tags}
+	var resp URLResponse
+	if err := c.do(ctx, http.MethodPost, codePath("/api/urls", code, "tags"), c.codeQuery(), body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RemoveTag removes a tag from a short URL
+func (c *Client) RemoveTag(ctx context.Context, code, tag string) error {
+	return c.do(ctx, http.MethodDelete, codePath("/api/urls", code, "tags", url.PathEscape(tag)), c.codeQuery(), nil, nil)
+}
+
+// SetFolder moves a short URL into a folder
+func (c *Client) SetFolder(ctx context.Context, code, folder string) (*URLResponse, error) {
+	body := struct {
+		Folder string `json:"folder"`
+	}{folder}
+	var resp URLResponse
+	if err := c.do(ctx, http.MethodPut, codePath("/api/urls", code, "folder"), c.codeQuery(), body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ClearFolder moves a short URL out of its folder
+func (c *Client) ClearFolder(ctx context.Context, code string) (*URLResponse, error) {
+	var resp URLResponse
+	if err := c.do(ctx, http.MethodDelete, codePath("/api/urls", code, "folder"), c.codeQuery(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListTags retrieves every tag in use with its link and visit counts
+func (c *Client) ListTags(ctx context.Context) ([]*TagStats, error) {
+	var resp struct {
+		Tags []*TagStats `json:"tags"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/tags", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Tags, nil
+}
+
+// GetTagStats retrieves usage statistics of the links with a tag
+func (c *Client) GetTagStats(ctx context.Context, tag string) (*Stats, error) {
+	var stats Stats
+	if err := c.do(ctx, http.MethodGet, codePath("/api/tags", tag, "stats"), nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// ListFolders retrieves every folder in use with its link and visit counts
+func (c *Client) ListFolders(ctx context.Context) ([]*FolderStats, error) {
+	var resp struct {
+		Folders []*FolderStats `json:"folders"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/folders", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Folders, nil
+}
+
+// CreateCollection creates a collection
+func (c *Client) CreateCollection(ctx context.Context, req CreateCollectionRequest) (*Collection, error) {
+	var collection Collection
+	if err := c.do(ctx, http.MethodPost, "/api/collections", nil, req, &collection); err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// GetCollection retrieves a collection
+func (c *Client) GetCollection(ctx context.Context, slug string) (*Collection, error) {
+	var collection Collection
+	if err := c.do(ctx, http.MethodGet, codePath("/api/collections", slug), nil, nil, &collection); err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// DeleteCollection deletes a collection
+func (c *Client) DeleteCollection(ctx context.Context, slug string) error {
+	return c.do(ctx, http.MethodDelete, codePath("/api/collections", slug), nil, nil, nil)
+}
+
+// AddCollectionItem adds a short URL to a collection
+func (c *Client) AddCollectionItem(ctx context.Context, slug string, req CollectionItemRequest) (*CollectionItem, error) {
+	var item CollectionItem
+	if err := c.do(ctx, http.MethodPost, codePath("/api/collections", slug, "items"), nil, req, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ReorderCollectionItems sets the order of the items of a collection
+func (c *Client) ReorderCollectionItems(ctx context.Context, slug string, itemIDs []int64) (*Collection, error) {
+	body := struct {
+		ItemIDs []int64 `json:"item_ids"`
+	}{itemIDs}
+	var collection Collection
+	if err := c.do(ctx, http.MethodPut, codePath("/api/collections", slug, "items", "order"), nil, body, &collection); err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// RemoveCollectionItem removes an item from a collection
+func (c *Client) RemoveCollectionItem(ctx context.Context, slug string, itemID int64) error {
+	path := codePath("/api/collections", slug, "items", strconv.FormatInt(itemID, 10))
+	return c.do(ctx, http.MethodDelete, path, nil, nil, nil)
+}
+
+// CreateDomain registers a custom domain
+func (c *Client) CreateDomain(ctx context.Context, host string) (*Domain, error) {
+	body := struct {
+		Host string `json:"host"`
+	}{host}
+	var domain Domain
+	if err := c.do(ctx, http.MethodPost, "/api/domains", nil, body, &domain); err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+// ListDomains retrieves the custom domains
+func (c *Client) ListDomains(ctx context.Context) ([]*Domain, error) {
+	var domains []*Domain
+	if err := c.do(ctx, http.MethodGet, "/api/domains", nil, nil, &domains); err != nil {
+		return nil, err
+	}
+	return domains, nil
+}
+
+// DeleteDomain removes a custom domain
+func (c *Client) DeleteDomain(ctx context.Context, host string) error {
+	return c.do(ctx, http.MethodDelete, codePath("/api/domains", host), nil, nil, nil)
+}
+
+// ReportURL reports an abusive short URL
+func (c *Client) ReportURL(ctx context.Context, code string, req CreateReportRequest) (*ReportReceipt, error) {
+	var receipt ReportReceipt
+	if err := c.do(ctx, http.MethodPost, codePath("/api/report", code), c.codeQuery(), req, &receipt); err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// setQuery sets a query parameter unless the value is empty
+func setQuery(query url.Values, name, value string) {
+	if value != "" {
+		query.Set(name, value)
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Health calls the basic status check
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var health Health
+	if err := c.do(ctx, http.MethodGet, "/health", nil, nil, &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// Livez runs the liveness probe. A failing probe returns its report along
+// with an *Error naming the failed checks.
+func (c *Client) Livez(ctx context.Context) (*ProbeReport, error) {
+	return c.probe(ctx, "/livez")
+}
+
+// Readyz runs the readiness probe. A failing probe returns its report along
+// with an *Error naming the failed checks.
+func (c *Client) Readyz(ctx context.Context) (*ProbeReport, error) {
+	return c.probe(ctx, "/readyz")
+}
+
+// probe runs a probe, decoding the report of failing probes too
+func (c *Client) probe(ctx context.Context, path string) (*ProbeReport, error) {
+	resp, err := c.request(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil, &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	}
+
+	var report ProbeReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("nano-link: decoding %s response: %w", path, err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return &report, nil
+	}
+
+	var failed []string
+	for name, check := range report.Checks {
+		if !strings.EqualFold(check.Status, "ok") {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+	return &report, &Error{StatusCode: resp.StatusCode, Message: "failed checks: " + strings.Join(failed, ", ")}
+}
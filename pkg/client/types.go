@@ -0,0 +1,153 @@
+package client
+
+import (
+	"time"
+
+	"github.com/nijaru/nano-link/internal/models"
+	"github.com/nijaru/nano-link/internal/probes"
+)
+
+// Models shared with the server, so that responses decode exactly as they
+// were encoded
+type (
+	URL              = models.URL
+	URLResponse      = models.URLResponse
+	Stats            = models.Stats
+	SocialCard       = models.SocialCard
+	LinkHealth       = models.LinkHealth
+	Preview          = models.Preview
+	CountryVisits    = models.CountryVisits
+	TagStats         = models.TagStats
+	FolderStats      = models.FolderStats
+	Collection       = models.CollectionResponse
+	CollectionItem   = models.CollectionItemResponse
+	Domain           = models.Domain
+	Report           = models.Report
+	Webhook          = models.Webhook
+	WebhookDelivery  = models.WebhookDelivery
+	LinkEvent        = models.LinkEvent
+	ProbeReport      = probes.Report
+	ProbeCheckResult = probes.CheckResult
+)
+
+// Link events that webhooks can subscribe to
+const (
+	EventLinkCreated  = models.EventLinkCreated
+	EventLinkUpdated  = models.EventLinkUpdated
+	EventLinkDisabled = models.EventLinkDisabled
+	EventLinkEnabled  = models.EventLinkEnabled
+	EventLinkDeleted  = models.EventLinkDeleted
+	EventLinkExpired  = models.EventLinkExpired
+	EventLinkClicked  = models.EventLinkClicked
+)
+
+// CreateURLRequest describes a short URL to create
+type CreateURLRequest struct {
+	URL          string            `json:"url"`
+	CustomCode   string            `json:"custom_code,omitempty"`
+	GeoTargets   map[string]string `json:"geo_targets,omitempty"` // country code -> destination
+	Password     string            `json:"password,omitempty"`
+	Owner        string            `json:"owner,omitempty"`
+	Interstitial bool              `json:"interstitial,omitempty"`
+	FallbackURL  string            `json:"fallback_url,omitempty"` // web page for non-HTTP destinations
+	Domain       string            `json:"domain,omitempty"`       // registered custom domain; empty for the default
+	Tags         []string          `json:"tags,omitempty"`
+	Folder       string            `json:"folder,omitempty"`
+	Title        string            `json:"title,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Notes        string            `json:"notes,omitempty"`
+	Card         *SocialCard       `json:"card,omitempty"`
+}
+
+// UpdateURLRequest lists the fields of a short URL to change; nil fields are
+// kept. An empty card removes the custom card.
+type UpdateURLRequest struct {
+	Title       *string     `json:"title,omitempty"`
+	Description *string     `json:"description,omitempty"`
+	Notes       *string     `json:"notes,omitempty"`
+	Card        *SocialCard `json:"card,omitempty"`
+}
+
+// ListURLsOptions filters and orders a listing of short URLs. Zero values
+// are left to the server defaults.
+type ListURLsOptions struct {
+	Limit         int    // 1 to 100
+	Sort          string // "created", "visits" or "last_visited"
+	Ascending     bool
+	Cursor        string // NextCursor of the previous page
+	Destination   string // destination host, matching its subdomains too
+	Query         string // substring of the destination, short code or title
+	Owner         string
+	Status        string // "active" or "disabled"
+	Health        string // "broken", "healthy" or "unchecked"
+	Tag           string
+	Folder        string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// URLPage is one page of a short URL listing
+type URLPage struct {
+	URLs       []URLResponse `json:"urls"`
+	NextCursor string        `json:"next_cursor,omitempty"` // empty on the last page
+}
+
+// QROptions controls a QR code image. Zero values are left to the server
+// defaults.
+type QROptions struct {
+	Format     string // "png" or "svg"
+	Size       int    // width and height in pixels
+	Margin     *int   // quiet zone in modules
+	Level      string // error correction: "L", "M", "Q" or "H"
+	Foreground string // rrggbb
+	Background string // rrggbb
+	Logo       bool
+}
+
+// CreateCollectionRequest describes a collection to create
+type CreateCollectionRequest struct {
+	Slug        string                  `json:"slug"`
+	Title       string                  `json:"title"`
+	Description string                  `json:"description,omitempty"`
+	Items       []CollectionItemRequest `json:"items,omitempty"`
+}
+
+// CollectionItemRequest describes a short URL to list in a collection
+type CollectionItemRequest struct {
+	Code   string `json:"code"`
+	Domain string `json:"domain,omitempty"` // custom domain of the code; empty for the default
+	Title  string `json:"title"`
+	Icon   string `json:"icon,omitempty"`
+}
+
+// CreateReportRequest describes an abuse report
+type CreateReportRequest struct {
+	Reason  string `json:"reason"` // phishing, malware, spam, illegal or other
+	Details string `json:"details,omitempty"`
+	Email   string `json:"email,omitempty"`
+}
+
+// ReportReceipt acknowledges a stored abuse report
+type ReportReceipt struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+}
+
+// CreateWebhookRequest describes a webhook subscription
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"` // generated if empty
+}
+
+// ListDeliveriesOptions filters a listing of webhook deliveries
+type ListDeliveriesOptions struct {
+	Status string // "pending", "delivered" or "dead"
+	Limit  int    // up to 500
+}
+
+// Health is the response of the basic status check
+type Health struct {
+	Status string    `json:"status"`
+	Time   time.Time `json:"time"`
+}